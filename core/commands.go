@@ -156,7 +156,7 @@ func AddSlashCommands(guildId string, c chan string) {
 		if err != nil {
 			c <- "Unable to register slash commands :/"
 			log.Errorf("Cannot create '%v' command: %v", v.Name, err)
-			log.Errorf("%s", v.Options)
+			log.Errorf("%v", v.Options)
 			return
 		}
 	}
@@ -20,6 +20,16 @@ func ToPtr[T any](v T) *T {
 	return &v
 }
 
+// Contains reports whether a slice contains the given value
+func Contains[T comparable](items []T, v T) bool {
+	for _, item := range items {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
 //
 //func Find[T any](items []T, fn func(item T) bool) T {
 //	foundItem := T{}
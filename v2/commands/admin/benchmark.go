@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// benchmark.go
+// Times the argument parsers on synthetic input, to spot slowdowns without a dev environment
+
+var benchmarkInfo = bot.CreateCommandInfo("benchmark", "Times the argument parsers on synthetic input", false, bot.Utility).
+	AddArg("runs", bot.Int, bot.ArgOption, "How many times to run each parser", false, "1000")
+
+// maxBenchmarkRuns
+// The most runs allowed, so the command can't hold up the bot for long.
+const maxBenchmarkRuns = 100000
+
+func benchmark(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can run benchmarks
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, "Benchmark", "Sorry, only Bot Administrators can run benchmarks!", 0)
+		return
+	}
+	runs := ctx.Args["runs"].IntValue()
+	if runs < 1 || runs > maxBenchmarkRuns {
+		response.Send(false, "Benchmark", fmt.Sprintf("The number of runs must be between 1 and %d", maxBenchmarkRuns), 0)
+		return
+	}
+	timings := bot.BenchmarkParsers(runs)
+	lines := make([]string, len(timings))
+	for i, timing := range timings {
+		lines[i] = fmt.Sprintf("`%s`: %s per run (%s total)", timing.Name, timing.PerRun(), timing.Total)
+	}
+	response.Send(true, fmt.Sprintf("Benchmark (%d runs)", runs), strings.Join(lines, "\n"), 0)
+}
+
+func init() {
+	bot.AddCommand(benchmarkInfo, benchmark)
+}
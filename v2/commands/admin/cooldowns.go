@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"fmt"
+
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// cooldowns.go
+// Lets admins clear a user's, channel's or guild's cooldowns, for testing or as a reward
+
+var resetCooldownsInfo = bot.CreateCommandInfo("resetcooldowns", "Clears command cooldowns", false, bot.Utility).
+	AddFlagArg("scope", bot.String, bot.ArgOption, "Clear a user's cooldowns, or the ones shared by this channel or guild", false, "user").
+	AddArg("user", bot.User, bot.ArgOption, "The user to clear cooldowns for, with the user scope", false, "").
+	AddArg("command", bot.String, bot.ArgContent, "Only clear the cooldown for this command", false, "").
+	AddChoices("scope", []string{"user", "channel", "guild"})
+
+func resetCooldowns(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can clear cooldowns
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, "Cooldowns", "Sorry, only Bot Administrators can clear cooldowns!", 0)
+		return
+	}
+	var scope bot.CooldownScope
+	var id, target string
+	switch ctx.Args["scope"].StringValue() {
+	case "channel":
+		scope, id, target = bot.CooldownChannel, ctx.ChannelID(), "this channel"
+	case "guild":
+		if ctx.GuildID() == "" {
+			response.Send(false, "Cooldowns", "Guild cooldowns can only be cleared in a guild", 0)
+			return
+		}
+		scope, id, target = bot.CooldownGuild, ctx.GuildID(), "this guild"
+	default:
+		if ctx.Args["user"].StringValue() == "" {
+			response.Send(false, "Cooldowns", "Please give the user to clear cooldowns for", 0)
+			return
+		}
+		user, err := ctx.Args["user"].UserValue(bot.Session)
+		if err != nil {
+			response.Send(false, "Cooldowns", "Unable to find that user", 0)
+			return
+		}
+		scope, id, target = bot.CooldownUser, user.ID, user.Mention()
+	}
+	var cleared int
+	if trigger := ctx.Args["command"].StringValue(); trigger != "" {
+		cleared = bot.ClearScopedCooldown(scope, id, trigger)
+	} else {
+		cleared = bot.ClearAllScopedCooldowns(scope, id)
+	}
+	response.Send(true, "Cooldowns", fmt.Sprintf("Cleared %d cooldown(s) for %s", cleared, target), 0)
+}
+
+func init() {
+	bot.AddCommand(resetCooldownsInfo, resetCooldowns)
+}
@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// errors.go
+// Shows the most recent error reports, so they don't have to be dug out of DMs
+
+var errorsInfo = bot.CreateCommandInfo("errors", "Shows the most recent error reports", false, bot.Utility)
+
+// shownErrors
+// The most error reports shown at once.
+const shownErrors = 10
+
+// maxErrorLine
+// The longest a single error message is allowed to be in the list.
+const maxErrorLine = 200
+
+func recentErrors(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can see error reports
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, "Errors", "Sorry, only Bot Administrators can view error reports!", 0)
+		return
+	}
+	records := bot.RecentErrors()
+	if len(records) == 0 {
+		response.Send(true, "Recent errors", "There haven't been any errors since the bot started", 0)
+		return
+	}
+	if len(records) > shownErrors {
+		records = records[:shownErrors]
+	}
+	lines := make([]string, len(records))
+	for i, record := range records {
+		message := record.Message
+		if runes := []rune(message); len(runes) > maxErrorLine {
+			message = string(runes[:maxErrorLine]) + "..."
+		}
+		// Only show the context that the report actually has
+		var details []string
+		if record.Command != "" {
+			details = append(details, "command `"+record.Command+"`")
+		}
+		if record.GuildID != "" {
+			details = append(details, "guild "+record.GuildID)
+		}
+		if record.UserID != "" {
+			details = append(details, "user "+record.UserID)
+		}
+		line := fmt.Sprintf("<t:%d:R> %s", record.Time.Unix(), message)
+		if len(details) > 0 {
+			line += " (" + strings.Join(details, ", ") + ")"
+		}
+		lines[i] = line
+	}
+	response.Send(true, "Recent errors", strings.Join(lines, "\n"), 0)
+}
+
+func init() {
+	bot.AddCommand(errorsInfo, recentErrors)
+}
@@ -0,0 +1,148 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// eval.go
+// A small set of introspection functions the bot owner can call for live debugging
+// This isn't a Go evaluator, only the functions in evalFuncs can be called.
+// It is never added as a slash command, and only runs for bot admins in the owner guild.
+
+var evalInfo = bot.CreateCommandInfo("eval", "Calls a debugging function; owner only", false, bot.Utility).
+	AddArg("call", bot.String, bot.ArgContent, "The function to call and its arguments; leave empty to list them", false, "")
+
+// evalFunc
+// A function that can be called with eval, given the arguments after its name.
+type evalFunc struct {
+	usage string
+	run   func(ctx *bot.CmdContext, args []string) (string, error)
+}
+
+// evalFuncs
+// Every function eval can call, keyed by name.
+var evalFuncs = map[string]evalFunc{
+	"guilds": {
+		usage: "guilds - lists the guilds the bot is in",
+		run: func(_ *bot.CmdContext, _ []string) (string, error) {
+			bot.Session.State.RLock()
+			defer bot.Session.State.RUnlock()
+			lines := make([]string, 0, len(bot.Session.State.Guilds))
+			for _, g := range bot.Session.State.Guilds {
+				lines = append(lines, fmt.Sprintf("%s %s (%d members)", g.ID, g.Name, g.MemberCount))
+			}
+			sort.Strings(lines)
+			return strings.Join(lines, "\n"), nil
+		},
+	},
+	"config": {
+		usage: "config [guild id] - dumps a guild's config, this guild by default",
+		run: func(ctx *bot.CmdContext, args []string) (string, error) {
+			g, err := evalGuild(ctx, args)
+			if err != nil {
+				return "", err
+			}
+			data, err := g.ExportJSON()
+			return string(data), err
+		},
+	},
+	"reload": {
+		usage: "reload [guild id] - reloads a guild's custom commands from storage, this guild by default",
+		run: func(ctx *bot.CmdContext, args []string) (string, error) {
+			g, err := evalGuild(ctx, args)
+			if err != nil {
+				return "", err
+			}
+			if err = g.ReloadCustomCommands(); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("reloaded %d custom commands in %s", g.CustomCommandCount(), g.ID), nil
+		},
+	},
+	"command": {
+		usage: "command <trigger> - describes a command and its children",
+		run: func(_ *bot.CmdContext, args []string) (string, error) {
+			if len(args) == 0 {
+				return "", errors.New("no trigger was given")
+			}
+			desc, err := bot.DescribeCommand(args[0])
+			if err != nil {
+				return "", err
+			}
+			children := make([]string, len(desc.Children))
+			for i, child := range desc.Children {
+				children[i] = child.Info.Trigger
+			}
+			return fmt.Sprintf("trigger: %s\naliases: %s\nchildren: %s\nslash: %t\npublic: %t",
+				desc.Info.Trigger, strings.Join(desc.Aliases, ", "), strings.Join(children, ", "), desc.Slash, desc.Info.Public), nil
+		},
+	},
+	"uptime": {
+		usage: "uptime - how long the bot has been running",
+		run: func(_ *bot.CmdContext, _ []string) (string, error) {
+			return bot.Uptime().Round(time.Second).String(), nil
+		},
+	},
+}
+
+// evalGuild
+// Returns the guild given as the first argument, or the current guild if there isn't one
+// Only guilds the bot is in can be given, so eval can't register new guilds by mistake.
+func evalGuild(ctx *bot.CmdContext, args []string) (*bot.Guild, error) {
+	if len(args) == 0 {
+		return ctx.Guild, nil
+	}
+	if _, err := bot.Session.State.Guild(args[0]); err != nil {
+		return nil, fmt.Errorf("the bot isn't in guild %s", args[0])
+	}
+	return bot.GetGuild(args[0]), nil
+}
+
+// evalUsage
+// Lists every function eval can call.
+func evalUsage() string {
+	lines := make([]string, 0, len(evalFuncs))
+	for _, fn := range evalFuncs {
+		lines = append(lines, fn.usage)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+func eval(ctx *bot.CmdContext) {
+	// Checked again here, so eval stays gated even if the command info is changed
+	if !bot.IsAdmin(ctx.AuthorID()) || !bot.IsOwnerGuild(ctx.Guild.ID) {
+		response := bot.NewResponse(ctx, false, false, 0)
+		response.Send(false, "Eval", "Sorry, only Bot Administrators can use eval in the owner guild!", 0)
+		return
+	}
+	call := strings.Fields(ctx.Args["call"].StringValue())
+	output := evalUsage()
+	if len(call) > 0 {
+		fn, ok := evalFuncs[strings.ToLower(call[0])]
+		if !ok {
+			output = "unknown function " + call[0] + "\n\n" + output
+		} else if result, err := fn.run(ctx, call[1:]); err != nil {
+			output = "error: " + err.Error()
+		} else {
+			output = result
+		}
+	}
+	if output == "" {
+		output = "(no output)"
+	}
+	if err := ctx.ReplyLong("```\n" + output + "\n```"); err != nil {
+		bot.Log.Errorf("unable to send eval output: %s", err)
+	}
+}
+
+func init() {
+	evalInfo.OwnerGuildOnly = true
+	bot.AddCommand(evalInfo, eval)
+}
@@ -0,0 +1,54 @@
+package admin
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// gc.go
+// Runs a garbage collection on demand and reports how much memory it freed
+
+var gcInfo = bot.CreateCommandInfo("gc", "Runs a garbage collection and returns freed memory to the OS", false, bot.Utility)
+
+// freed
+// Formats how much a memory stat went down by, or up by if it grew.
+func freed(before uint64, after uint64) string {
+	if after > before {
+		return "+" + formatBytes(after-before)
+	}
+	return "-" + formatBytes(before-after)
+}
+
+func gc(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can force a garbage collection
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, "GC", "Sorry, only Bot Administrators can run a garbage collection!", 0)
+		return
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	debug.FreeOSMemory()
+	took := time.Since(start)
+	runtime.ReadMemStats(&after)
+	var released uint64
+	if after.HeapReleased > before.HeapReleased {
+		released = after.HeapReleased - before.HeapReleased
+	}
+	response.AppendField(0, "Heap in use:", formatBytes(before.HeapInuse)+" → "+formatBytes(after.HeapInuse)+" ("+freed(before.HeapInuse, after.HeapInuse)+")", false)
+	response.AppendField(0, "Allocated:", formatBytes(before.Alloc)+" → "+formatBytes(after.Alloc)+" ("+freed(before.Alloc, after.Alloc)+")", false)
+	response.AppendField(0, "Returned to the OS:", formatBytes(released), true)
+	response.AppendField(0, "From the OS:", formatBytes(after.Sys), true)
+	response.AppendField(0, "Objects freed:", strconv.FormatUint(after.Frees-before.Frees, 10), true)
+	response.AppendField(0, "Took:", took.Round(time.Microsecond).String(), true)
+	response.Send(true, "GC", "", 0)
+}
+
+func init() {
+	bot.AddCommand(gcInfo, gc)
+}
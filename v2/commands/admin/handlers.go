@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// handlers.go
+// Lists the registered component handlers, to help track down handlers that pile up
+
+var handlersInfo = bot.CreateCommandInfo("handlers", "Lists the registered component handlers", false, bot.Utility)
+
+// maxHandlerLines
+// The most handlers listed, so a leak can't make the response too long to send.
+const maxHandlerLines = 40
+
+// handlerLine
+// Formats a single handler for the list.
+func handlerLine(handler bot.InteractionHandler) string {
+	line := fmt.Sprintf("`%s` added <t:%d:R>", handler.Info.Id, handler.RegisteredAt.Unix())
+	if !handler.ExpiresAt.IsZero() {
+		line += fmt.Sprintf(", expires <t:%d:R>", handler.ExpiresAt.Unix())
+	}
+	if handler.Info.UserID != "" {
+		line += ", only for <@" + handler.Info.UserID + ">"
+	}
+	return line
+}
+
+func handlers(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can see the handlers
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, "Handlers", "Sorry, only Bot Administrators can view the handlers!", 0)
+		return
+	}
+	var permanent, temporary []string
+	for _, handler := range bot.GetInteractHandlers() {
+		if handler.ExpiresAt.IsZero() {
+			permanent = append(permanent, handlerLine(handler))
+		} else {
+			temporary = append(temporary, handlerLine(handler))
+		}
+	}
+	response.AppendField(0, "Permanent:", strconv.Itoa(len(permanent)), true)
+	response.AppendField(0, "Temporary:", strconv.Itoa(len(temporary)), true)
+
+	// Temporary handlers are listed first, since they are the ones that can leak
+	lines := append(temporary, permanent...)
+	if len(lines) > maxHandlerLines {
+		more := len(lines) - maxHandlerLines
+		lines = append(lines[:maxHandlerLines], "...and "+strconv.Itoa(more)+" more")
+	}
+	description := strings.Join(lines, "\n")
+	if description == "" {
+		description = "No handlers are registered"
+	}
+	response.Send(true, "Handlers", description, 0)
+}
+
+func init() {
+	bot.AddCommand(handlersInfo, handlers)
+}
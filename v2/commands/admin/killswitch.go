@@ -0,0 +1,43 @@
+package admin
+
+import (
+	"strings"
+
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// killswitch.go
+// Turns a misbehaving command off everywhere at once, or back on
+
+var killswitchInfo = bot.CreateCommandInfo("killswitch", "Turns a command off or on in every guild", false, bot.Utility).
+	AddArg("command", bot.String, bot.ArgContent, "The command to toggle; leave empty to list turned off commands", false, "")
+
+func killswitch(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can turn commands off
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, "Killswitch", "Sorry, only Bot Administrators can turn commands off!", 0)
+		return
+	}
+	trigger := ctx.Args["command"].StringValue()
+	if trigger == "" {
+		disabled := bot.GloballyDisabledCommands()
+		if len(disabled) == 0 {
+			response.Send(true, "Killswitch", "No commands are turned off", 0)
+			return
+		}
+		response.Send(true, "Killswitch", "Turned off: `"+strings.Join(disabled, "`, `")+"`", 0)
+		return
+	}
+	if bot.IsCommandDisabledGlobally(trigger) {
+		bot.EnableCommandGlobally(trigger)
+		response.Send(true, "Killswitch", "`"+trigger+"` has been turned back on", 0)
+		return
+	}
+	bot.DisableCommandGlobally(trigger)
+	response.Send(true, "Killswitch", "`"+trigger+"` has been turned off everywhere", 0)
+}
+
+func init() {
+	bot.AddCommand(killswitchInfo, killswitch)
+}
@@ -0,0 +1,33 @@
+package admin
+
+import (
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// maintenance.go
+// Puts the bot into maintenance mode before a deploy, or takes it back out
+
+var maintenanceInfo = bot.CreateCommandInfo("maintenance", "Turns maintenance mode on or off, so only bot admins can use commands", false, bot.Utility).
+	AddArg("mode", bot.String, bot.ArgOption, "Whether maintenance mode is on or off", true, "").
+	AddArg("message", bot.String, bot.ArgContent, "What everyone else is told while it is on; leave empty for the default", false, "").
+	AddChoices("mode", []string{"on", "off"})
+
+func maintenance(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can turn maintenance mode on
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, "Maintenance", "Sorry, only Bot Administrators can change maintenance mode!", 0)
+		return
+	}
+	if ctx.Args["mode"].StringValue() == "off" {
+		bot.SetMaintenanceMode(false, "")
+		response.Send(true, "Maintenance", "Maintenance mode is off, everyone can use commands again", 0)
+		return
+	}
+	bot.SetMaintenanceMode(true, ctx.Args["message"].StringValue())
+	response.Send(true, "Maintenance", "Maintenance mode is on, only bot admins can use commands", 0)
+}
+
+func init() {
+	bot.AddCommand(maintenanceInfo, maintenance)
+}
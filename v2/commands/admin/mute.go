@@ -0,0 +1,52 @@
+package admin
+
+import (
+	"strings"
+	"time"
+
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// mute.go
+// Stops the bot from responding in a channel for a while, e.g. during an incident
+
+var muteInfo = bot.CreateCommandInfo("mute", "Stops the bot from responding in a channel for a while", false, bot.Utility).
+	AddArg("time", bot.String, bot.ArgOption, "How long to mute the channel for, e.g. 30m; off to unmute it", true, "").
+	AddArg("channel", bot.Channel, bot.ArgOption, "The channel to mute; defaults to this one", false, "")
+
+func mute(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can mute the bot, and they are the only ones it still answers
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, "Mute", "Sorry, only Bot Administrators can mute the bot!", 0)
+		return
+	}
+	channelID := ctx.ChannelID()
+	if ctx.Args["channel"].StringValue() != "" {
+		channel, err := ctx.Args["channel"].ChannelValue(bot.Session)
+		if err != nil || channel == nil || channel.GuildID != ctx.Guild.ID {
+			response.Send(false, "Mute", "Unable to find that channel in this guild", 0)
+			return
+		}
+		channelID = channel.ID
+	}
+	if strings.EqualFold(ctx.Args["time"].StringValue(), "off") {
+		if !ctx.Guild.UnmuteChannel(channelID) {
+			response.Send(false, "Mute", "<#"+channelID+"> isn't muted", 0)
+			return
+		}
+		response.Send(true, "Mute", "The bot will respond in <#"+channelID+"> again", 0)
+		return
+	}
+	duration, ok := bot.ParseDuration(ctx.Args["time"].StringValue())
+	if !ok || duration < time.Second {
+		response.Send(false, "Mute", "That isn't a valid amount of time, try something like 30m or 2h", 0)
+		return
+	}
+	ctx.Guild.MuteChannel(channelID, time.Now().Add(duration))
+	response.Send(true, "Mute", "The bot won't respond in <#"+channelID+"> for "+bot.FormatDuration(duration), 0)
+}
+
+func init() {
+	bot.AddCommand(muteInfo, mute)
+}
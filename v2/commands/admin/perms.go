@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// perms.go
+// Shows which permission checks apply to a user, to explain why they can or can't run a command
+
+var permsInfo = bot.CreateCommandInfo("perms", "Shows which permission checks apply to a user", false, bot.Utility).
+	AddArg("user", bot.User, bot.ArgOption, "The user to check; defaults to you", false, "").
+	SetDefaultToInvoker("user")
+
+// yesNo
+// Formats a check's result for the response.
+func yesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
+
+// matchingIDs
+// Returns the IDs in list that are the member's ID or one of their roles.
+func matchingIDs(member *discordgo.Member, list []string) []string {
+	var matches []string
+	for _, id := range list {
+		if id == member.User.ID {
+			matches = append(matches, "<@"+id+">")
+			continue
+		}
+		for _, role := range member.Roles {
+			if id == role {
+				matches = append(matches, "<@&"+id+">")
+				break
+			}
+		}
+	}
+	return matches
+}
+
+func perms(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins and mods can see permissions
+	if !bot.IsAdmin(ctx.AuthorID()) && !ctx.Guild.IsMod(ctx.AuthorID()) {
+		response.Send(false, "Permissions", "Sorry, only Bot Administrators and moderators can view permissions!", 0)
+		return
+	}
+	member, err := ctx.Args["user"].MemberValue(bot.Session, ctx.Guild.ID)
+	if err != nil {
+		response.Send(false, "Permissions", "Unable to find that member in this guild", 0)
+		return
+	}
+
+	response.AppendField(0, "User:", member.User.Mention(), false)
+	response.AppendField(0, "Bot admin:", yesNo(bot.IsAdmin(member.User.ID)), true)
+	mod := matchingIDs(member, append(ctx.Guild.ModRoles(), ctx.Guild.Info.ModeratorIDs...))
+	modText := yesNo(len(mod) > 0)
+	if len(mod) > 0 {
+		modText += " (" + strings.Join(mod, ", ") + ")"
+	}
+	response.AppendField(0, "Guild mod:", modText, true)
+
+	disabled := "None"
+	if list := bot.GloballyDisabledCommands(); len(list) > 0 {
+		disabled = "`" + strings.Join(list, "`, `") + "`"
+		if bot.IsAdmin(member.User.ID) {
+			disabled += " (bypassed as a bot admin)"
+		}
+	}
+	response.AppendField(0, "Turned off everywhere:", disabled, false)
+	response.Send(true, "Permissions", "", 0)
+}
+
+func init() {
+	bot.AddCommand(permsInfo, perms)
+}
@@ -0,0 +1,37 @@
+package admin
+
+import (
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// presence.go
+// Changes the bot's status and activity, e.g: to show maintenance
+
+var presenceInfo = bot.CreateCommandInfo("presence", "Sets the bot's status and activity", false, bot.Utility).
+	AddArg("status", bot.String, bot.ArgOption, "The status to show, or reset for the default presence", true, "").
+	AddArg("activity", bot.String, bot.ArgContent, "What the bot is playing; start with watching, listening to or competing in to change how it is shown", false, "").
+	AddChoices("status", []string{"online", "idle", "dnd", "invisible", "reset"})
+
+func presence(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can change the presence
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, "Presence", "Sorry, only Bot Administrators can change the bot's presence!", 0)
+		return
+	}
+	status := ctx.Args["status"].StringValue()
+	if status == "reset" {
+		bot.ResetPresence()
+		response.Send(true, "Presence", "The default presence is back", 0)
+		return
+	}
+	if err := bot.SetPresence(status, ctx.Args["activity"].StringValue()); err != nil {
+		response.Send(false, "Presence", "Failed to set the presence: "+err.Error(), 0)
+		return
+	}
+	response.Send(true, "Presence", "The presence has been updated", 0)
+}
+
+func init() {
+	bot.AddCommand(presenceInfo, presence)
+}
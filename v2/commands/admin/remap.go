@@ -0,0 +1,54 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// remap.go
+// Renames a command's trigger, keeping the old trigger working as an alias
+
+var remapInfo = bot.CreateCommandInfo("remap", "Changes a command's trigger, keeping the old one as an alias", false, bot.Utility).
+	AddArg("command", bot.String, bot.ArgOption, "The command to remap; leave empty to list remaps", false, "").
+	AddArg("trigger", bot.String, bot.ArgOption, "The command's new trigger", false, "")
+
+func remap(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can remap commands
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, "Remap", "Sorry, only Bot Administrators can remap commands!", 0)
+		return
+	}
+	oldTrigger := ctx.Args["command"].StringValue()
+	newTrigger := ctx.Args["trigger"].StringValue()
+	if oldTrigger == "" {
+		remaps := bot.TriggerRemaps()
+		if len(remaps) == 0 {
+			response.Send(true, "Remap", "No commands have been remapped", 0)
+			return
+		}
+		lines := make([]string, 0, len(remaps))
+		for _, r := range remaps {
+			lines = append(lines, fmt.Sprintf("`%s` → `%s`", r.Old, r.New))
+		}
+		response.Send(true, "Remap", strings.Join(lines, "\n")+"\n\nRemaps made since the last restart take effect on the next one", 0)
+		return
+	}
+	if newTrigger == "" {
+		response.Send(false, "Remap", "Please give the command's new trigger", 0)
+		return
+	}
+	if err := bot.RemapTrigger(oldTrigger, newTrigger); err != nil {
+		response.Send(false, "Remap", "Failed to remap `"+oldTrigger+"`: "+err.Error(), 0)
+		return
+	}
+	response.Send(true, "Remap", "`"+oldTrigger+"` will be `"+strings.ToLower(newTrigger)+"` once the bot restarts, and the old trigger will still work.\n\n"+
+		"Commands can't be renamed while the bot is running, since they are looked up without locks while messages are handled. "+
+		"The slash command is registered under its new name on the next start.", 0)
+}
+
+func init() {
+	bot.AddCommand(remapInfo, remap)
+}
@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"time"
+
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// status.go
+// Reports the bot's health, to help track down leaks without attaching a profiler
+
+var statusInfo = bot.CreateCommandInfo("status", "Reports goroutines, memory use, uptime and counts", false, bot.Utility)
+
+// formatBytes
+// Formats a byte count in MiB, which is precise enough to spot a leak.
+func formatBytes(b uint64) string {
+	return fmt.Sprintf("%.1f MiB", float64(b)/(1<<20))
+}
+
+// commandCount
+// Counts every registered command, including child commands.
+func commandCount() int {
+	count := 0
+	for trigger, info := range bot.GetCommands() {
+		count++
+		if info.IsParent {
+			count += len(bot.GetChildCommands(trigger))
+		}
+	}
+	return count
+}
+
+// guildCount
+// Counts the guilds the bot is currently in.
+func guildCount() int {
+	bot.Session.State.RLock()
+	defer bot.Session.State.RUnlock()
+	return len(bot.Session.State.Guilds)
+}
+
+func status(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can see the bot's health
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, "Status", "Sorry, only Bot Administrators can view the bot's status!", 0)
+		return
+	}
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	response.AppendField(0, "Uptime:", bot.Uptime().Round(time.Second).String(), true)
+	response.AppendField(0, "Goroutines:", strconv.Itoa(runtime.NumGoroutine()), true)
+	response.AppendField(0, "Guilds:", strconv.Itoa(guildCount()), true)
+	response.AppendField(0, "Commands:", strconv.Itoa(commandCount()), true)
+	response.AppendField(0, "Heap in use:", formatBytes(mem.HeapInuse), true)
+	response.AppendField(0, "Allocated:", formatBytes(mem.Alloc), true)
+	response.AppendField(0, "From the OS:", formatBytes(mem.Sys), true)
+	response.AppendField(0, "GC cycles:", strconv.FormatUint(uint64(mem.NumGC), 10), true)
+	response.Send(true, "Status", "", 0)
+}
+
+func init() {
+	bot.AddCommand(statusInfo, status)
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// betarole.go
+// Sets the role whose members can try out experimental commands in this guild
+
+var configBetaRoleInfo = bot.CreateCommandInfo("betarole", "Sets the role that can use experimental commands", false, bot.Utility).
+	AddArg("role", bot.Role, bot.ArgOption, "The beta role; leave empty to remove it", false, "")
+
+func subCommandBetaRole(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can choose who tries experimental commands
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, configFail, "Sorry, only Bot Administrators can change the beta role!", 0)
+		return
+	}
+	if ctx.Args["role"].StringValue() == "" {
+		ctx.Guild.SetBetaRole("")
+		response.Send(true, "Beta role", "Experimental commands are now only available to Bot Administrators", 0)
+		return
+	}
+	role, err := ctx.Args["role"].RoleValue(bot.Session, ctx.Guild.ID)
+	if err != nil || role == nil {
+		response.Send(false, configFail, "Unable to find that role in this guild", 0)
+		return
+	}
+	ctx.Guild.SetBetaRole(role.ID)
+	response.Send(true, "Beta role", "Members of <@&"+role.ID+"> can now use experimental commands", 0)
+}
+
+func init() {
+	configBetaRoleInfo.SetParent(false, "config")
+	bot.AddChildCommand(configBetaRoleInfo, subCommandBetaRole)
+}
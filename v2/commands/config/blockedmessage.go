@@ -0,0 +1,29 @@
+package config
+
+import (
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+var configBlockedMessageInfo = bot.CreateCommandInfo("blockedmessage", "Sets the reply to commands that have been turned off", false, bot.Utility).
+	AddArg("message", bot.String, bot.ArgContent, "The reply, where {command} is the command's name; leave empty to stay silent", false, "")
+
+func subCommandBlockedMessage(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can change the reply to turned off commands
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, configFail, "Sorry, only Bot Administrators can change the blocked command reply!", 0)
+		return
+	}
+	msg := ctx.Args["message"].StringValue()
+	ctx.Guild.SetBlockedCommandMessage(msg)
+	if msg == "" {
+		response.Send(true, "Blocked command reply", "Commands that have been turned off will now be ignored silently", 0)
+		return
+	}
+	response.Send(true, "Blocked command reply", "Commands that have been turned off will now reply with: "+msg, 0)
+}
+
+func init() {
+	configBlockedMessageInfo.SetParent(false, "config")
+	bot.AddChildCommand(configBlockedMessageInfo, subCommandBlockedMessage)
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// config.go
+// The parent command for exporting and importing a guild's configuration
+
+var configFail = "Config"
+
+var configInfo = bot.CreateCommandInfo("config", "Export or import this guild's configuration", false, bot.Utility)
+
+func configCommand(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	response.Send(false, configFail, "Invalid syntax, you did not enter a sub command", 0)
+}
+
+func init() {
+	configInfo.SetParent(true, "")
+	bot.AddCommand(configInfo, configCommand)
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"bytes"
+
+	"github.com/bwmarrin/discordgo"
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+var configExportInfo = bot.CreateCommandInfo("export", "Uploads this guild's configuration as JSON", false, bot.Utility)
+
+func subCommandExport(ctx *bot.CmdContext) {
+	// Only bot admins can export a guild's configuration
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response := bot.NewResponse(ctx, false, false, 0)
+		response.Send(false, configFail, "Sorry, only Bot Administrators can export the configuration!", 0)
+		return
+	}
+	data, err := ctx.Guild.ExportJSON()
+	if err != nil {
+		bot.SendErrorReport(ctx.Guild.ID, ctx.ChannelID(), ctx.AuthorID(), "Unable to export guild configuration", err)
+		response := bot.NewResponse(ctx, false, false, 0)
+		response.Send(false, configFail, "Unable to export the configuration", 0)
+		return
+	}
+	_, err = bot.API.ChannelMessageSendComplex(ctx.ChannelID(), &discordgo.MessageSend{
+		Content: "Here is the configuration for this guild",
+		Files: []*discordgo.File{
+			{
+				Name:        ctx.Guild.ID + ".json",
+				ContentType: "application/json",
+				Reader:      bytes.NewReader(data),
+			},
+		},
+	})
+	if err != nil {
+		bot.SendErrorReport(ctx.Guild.ID, ctx.ChannelID(), ctx.AuthorID(), "Unable to upload guild configuration", err)
+	}
+}
+
+func init() {
+	configExportInfo.SetParent(false, "config")
+	bot.AddChildCommand(configExportInfo, subCommandExport)
+}
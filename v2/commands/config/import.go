@@ -0,0 +1,72 @@
+package config
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+var configImportInfo = bot.CreateCommandInfo("import", "Replaces this guild's configuration with an attached export", false, bot.Utility)
+
+// maxImportSize
+// The largest export that will be downloaded, in bytes.
+const maxImportSize = 1 << 20
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// downloadAttachment
+// Downloads an attachment, refusing anything larger than maxImportSize.
+func downloadAttachment(url string) ([]byte, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("unable to download attachment: " + resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImportSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImportSize {
+		return nil, errors.New("attachment is too large")
+	}
+	return data, nil
+}
+
+func subCommandImport(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can import a guild's configuration
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, configFail, "Sorry, only Bot Administrators can import the configuration!", 0)
+		return
+	}
+	if len(ctx.Message.Attachments) == 0 {
+		response.Send(false, configFail, "Attach an exported configuration to import it", 0)
+		return
+	}
+	attachment := ctx.Message.Attachments[0]
+	if attachment.Size > maxImportSize {
+		response.Send(false, configFail, "That file is too large to be a configuration export", 0)
+		return
+	}
+	data, err := downloadAttachment(attachment.URL)
+	if err != nil {
+		response.Send(false, configFail, "Unable to download the attachment: "+err.Error(), 0)
+		return
+	}
+	if err = ctx.Guild.ImportJSON(data); err != nil {
+		response.Send(false, configFail, "Unable to import the configuration: "+err.Error(), 0)
+		return
+	}
+	response.Send(true, "Config", "The configuration has been imported", 0)
+}
+
+func init() {
+	configImportInfo.SetParent(false, "config")
+	bot.AddChildCommand(configImportInfo, subCommandImport)
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+var configLanguageInfo = bot.CreateCommandInfo("language", "Sets the language of the bot's own messages in this guild", false, bot.Utility).
+	AddArg("code", bot.String, bot.ArgOption, "The language code, e.g. en; leave empty for the default", false, "")
+
+func subCommandLanguage(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can change the guild's language
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, configFail, "Sorry, only Bot Administrators can change the language!", 0)
+		return
+	}
+	ctx.Guild.SetLanguage(ctx.Args["code"].StringValue())
+	response.Send(true, "Language", "Messages in this guild will now be in `"+ctx.Guild.Language()+"`", 0)
+}
+
+func init() {
+	configLanguageInfo.SetParent(false, "config")
+	bot.AddChildCommand(configLanguageInfo, subCommandLanguage)
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+var configMentionHelpInfo = bot.CreateCommandInfo("mentionhelp", "Sets whether mentioning the bot replies with the prefix", false, bot.Utility).
+	AddArg("state", bot.String, bot.ArgOption, "Whether the reply is on or off", true, "").
+	AddChoices("state", []string{"on", "off"})
+
+func subCommandMentionHelp(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can change how the bot answers mentions
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, configFail, "Sorry, only Bot Administrators can change the mention reply!", 0)
+		return
+	}
+	switch ctx.Args["state"].StringValue() {
+	case "on":
+		ctx.Guild.SetMentionHelp(true)
+		response.Send(true, "Mention help", "Mentioning the bot will now reply with the prefix", 0)
+	case "off":
+		ctx.Guild.SetMentionHelp(false)
+		response.Send(true, "Mention help", "Mentioning the bot will now be ignored", 0)
+	default:
+		response.Send(false, configFail, "Invalid state, use `on` or `off`", 0)
+	}
+}
+
+func init() {
+	configMentionHelpInfo.SetParent(false, "config")
+	bot.AddChildCommand(configMentionHelpInfo, subCommandMentionHelp)
+}
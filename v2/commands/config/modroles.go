@@ -0,0 +1,60 @@
+package config
+
+import (
+	"strings"
+
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// modroles.go
+// Manages the roles whose members count as moderators in this guild
+
+var configModRolesInfo = bot.CreateCommandInfo("modroles", "Adds, removes, or lists the mod roles of this guild", false, bot.Utility).
+	AddArg("action", bot.String, bot.ArgOption, "What to do with the role", false, "list").
+	AddArg("role", bot.Role, bot.ArgOption, "The role to add or remove", false, "").
+	AddChoices("action", []string{"list", "add", "remove"})
+
+func subCommandModRoles(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can change who counts as a moderator
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, configFail, "Sorry, only Bot Administrators can change the mod roles!", 0)
+		return
+	}
+	action := strings.ToLower(ctx.Args["action"].StringValue())
+	if action == "list" {
+		roles := ctx.Guild.ModRoles()
+		if len(roles) == 0 {
+			response.Send(true, "Mod roles", "This guild has no mod roles", 0)
+			return
+		}
+		response.Send(true, "Mod roles", "<@&"+strings.Join(roles, ">, <@&")+">", 0)
+		return
+	}
+	if action != "add" && action != "remove" {
+		response.Send(false, configFail, "Invalid action, use `list`, `add`, or `remove`", 0)
+		return
+	}
+	role, err := ctx.Args["role"].RoleValue(bot.Session, ctx.Guild.ID)
+	if err != nil || role == nil {
+		response.Send(false, configFail, "Unable to find that role in this guild", 0)
+		return
+	}
+	done := "is now a mod role"
+	if action == "add" {
+		err = ctx.Guild.AddModRole(role.ID)
+	} else {
+		err = ctx.Guild.RemoveModRole(role.ID)
+		done = "is no longer a mod role"
+	}
+	if err != nil {
+		response.Send(false, configFail, "Unable to "+action+" the mod role: "+err.Error(), 0)
+		return
+	}
+	response.Send(true, "Mod roles", role.Mention()+" "+done, 0)
+}
+
+func init() {
+	configModRolesInfo.SetParent(false, "config")
+	bot.AddChildCommand(configModRolesInfo, subCommandModRoles)
+}
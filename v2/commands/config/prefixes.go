@@ -0,0 +1,36 @@
+package config
+
+import (
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// prefixes.go
+// Sets extra single-character prefixes, so e.g: both !ping and ?ping work
+
+var configPrefixesInfo = bot.CreateCommandInfo("prefixes", "Sets extra characters that also work as a prefix", false, bot.Utility).
+	AddArg("chars", bot.String, bot.ArgContent, "The characters, e.g: ?. to also respond to ?ping and .ping; leave empty to remove them", false, "")
+
+func subCommandPrefixes(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can change the prefixes
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, configFail, "Sorry, only Bot Administrators can change the prefixes!", 0)
+		return
+	}
+	chars := ctx.Args["chars"].StringValue()
+	if err := ctx.Guild.SetPrefixChars(chars); err != nil {
+		response.Send(false, configFail, "Unable to set the prefixes: "+err.Error(), 0)
+		return
+	}
+	prefix, prefixChars := ctx.Guild.Prefixes()
+	if prefixChars == "" {
+		response.Send(true, "Prefixes", "Only `"+prefix+"` works as a prefix now", 0)
+		return
+	}
+	response.Send(true, "Prefixes", "`"+prefix+"` and any of `"+prefixChars+"` now work as a prefix", 0)
+}
+
+func init() {
+	configPrefixesInfo.SetParent(false, "config")
+	bot.AddChildCommand(configPrefixesInfo, subCommandPrefixes)
+}
@@ -0,0 +1,22 @@
+package custom
+
+import (
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// custom.go
+// The parent command for managing a guild's custom commands
+
+var customFail = "Custom Commands"
+
+var customInfo = bot.CreateCommandInfo("custom", "Manage this guild's custom commands", false, bot.Utility)
+
+func customCommand(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	response.Send(false, customFail, "Invalid syntax, you did not enter a sub command", 0)
+}
+
+func init() {
+	customInfo.SetParent(true, "")
+	bot.AddCommand(customInfo, customCommand)
+}
@@ -0,0 +1,30 @@
+package custom
+
+import (
+	"fmt"
+
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+var customReloadInfo = bot.CreateCommandInfo("reload", "Reloads this guild's custom commands from storage", false, bot.Utility)
+
+func subCommandReload(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can reload custom commands
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, customFail, "Sorry, only Bot Administrators can reload custom commands!", 0)
+		return
+	}
+	err := ctx.Guild.ReloadCustomCommands()
+	if err != nil {
+		bot.Log.Errorf("unable to reload custom commands for guild %s: %s", ctx.Guild.ID, err)
+		response.Send(false, customFail, "Unable to reload the custom commands from storage", 0)
+		return
+	}
+	response.Send(true, "Custom commands reloaded", fmt.Sprintf("Loaded %d custom commands", ctx.Guild.CustomCommandCount()), 0)
+}
+
+func init() {
+	customReloadInfo.SetParent(false, "custom")
+	bot.AddChildCommand(customReloadInfo, subCommandReload)
+}
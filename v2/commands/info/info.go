@@ -0,0 +1,43 @@
+package info
+
+import (
+	"runtime"
+	"strconv"
+	"time"
+
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// info.go
+// Reports build and runtime information about the bot
+
+var infoInfo = bot.CreateCommandInfo("info", "Reports the bot's uptime, version and guild count", true, bot.Utility)
+
+// guildCount
+// Returns the number of guilds in the session state.
+func guildCount() int {
+	if bot.Session == nil || bot.Session.State == nil {
+		return 0
+	}
+	bot.Session.State.RLock()
+	defer bot.Session.State.RUnlock()
+	return len(bot.Session.State.Guilds)
+}
+
+func info(ctx *bot.CmdContext) {
+	version, commit := bot.VersionInfo()
+	if commit != "" {
+		version += " (" + commit + ")"
+	}
+	response := bot.NewResponse(ctx, false, false, 0)
+	response.AppendField(0, "Uptime:", bot.Uptime().Round(time.Second).String(), true)
+	response.AppendField(0, "Version:", version, true)
+	response.AppendField(0, "Go version:", runtime.Version(), true)
+	response.AppendField(0, "Guilds:", strconv.Itoa(guildCount()), true)
+	response.Send(true, "Bot Info", "", 0)
+}
+
+func init() {
+	bot.AddCommand(infoInfo, info)
+	bot.AddSlashCommand(infoInfo)
+}
@@ -0,0 +1,68 @@
+package info
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/ubergeek77/uberbot/internal"
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+var pingInfo = bot.CreateCommandInfo("ping", "Reports the bot's gateway and REST latency", true, bot.Utility)
+
+// pingEmbed
+// Creates the embed reporting both latencies.
+func pingEmbed(rest time.Duration) *discordgo.MessageEmbed {
+	return bot.CreateEmbed(bot.ColorSuccess, "Pong!", "", []*discordgo.MessageEmbedField{
+		bot.CreateField("Gateway heartbeat:", bot.Session.HeartbeatLatency().Round(time.Millisecond).String(), true),
+		bot.CreateField("REST round trip:", rest.Round(time.Millisecond).String(), true),
+	})
+}
+
+// The REST round trip is the time it takes to send the initial reply, which is then edited to show the results.
+func ping(ctx *bot.CmdContext) {
+	if ctx.Interaction != nil {
+		start := time.Now()
+		err := bot.API.InteractionRespond(ctx.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Pinging...",
+			},
+		})
+		if err != nil {
+			bot.Log.Errorf("unable to respond to ping: %s", err)
+			return
+		}
+		rest := time.Since(start)
+		_, err = bot.API.InteractionResponseEdit(ctx.Interaction, &discordgo.WebhookEdit{
+			Content: internal.ToPtr(""),
+			Embeds:  &[]*discordgo.MessageEmbed{pingEmbed(rest)},
+		})
+		if err != nil {
+			bot.Log.Errorf("unable to edit ping response: %s", err)
+		}
+		return
+	}
+
+	start := time.Now()
+	message, err := bot.API.ChannelMessageSend(ctx.ChannelID(), "Pinging...")
+	if err != nil {
+		bot.Log.Errorf("unable to respond to ping: %s", err)
+		return
+	}
+	rest := time.Since(start)
+	_, err = bot.API.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:      message.ID,
+		Channel: message.ChannelID,
+		Content: internal.ToPtr(""),
+		Embeds:  []*discordgo.MessageEmbed{pingEmbed(rest)},
+	})
+	if err != nil {
+		bot.Log.Errorf("unable to edit ping response: %s", err)
+	}
+}
+
+func init() {
+	bot.AddCommand(pingInfo, ping)
+	bot.AddSlashCommand(pingInfo)
+}
@@ -2,6 +2,11 @@ package commands
 
 // easy way of importing commands
 import (
+	_ "github.com/ubergeek77/uberbot/v2/commands/admin"
+	_ "github.com/ubergeek77/uberbot/v2/commands/config"
+	_ "github.com/ubergeek77/uberbot/v2/commands/custom"
 	_ "github.com/ubergeek77/uberbot/v2/commands/info"
+	_ "github.com/ubergeek77/uberbot/v2/commands/remind"
+	_ "github.com/ubergeek77/uberbot/v2/commands/slash"
 	_ "github.com/ubergeek77/uberbot/v2/commands/test"
 )
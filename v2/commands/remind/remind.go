@@ -0,0 +1,86 @@
+package remind
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// remind.go
+// Reminds a user about something after a delay. Reminders are scheduled jobs, so they survive a restart
+
+var remindFail = "Remind"
+
+var remindInfo = bot.CreateCommandInfo("remind", "Reminds you about something later", true, bot.Utility).
+	AddArg("time", bot.String, bot.ArgOption, "How long until the reminder, e.g: 1h30m", true, "").
+	AddArg("message", bot.String, bot.ArgContent, "What to remind you about", true, "")
+
+// remindJob
+// The name of the scheduled job that sends reminders.
+const remindJob = "remind"
+
+// reminder
+// The data saved with a reminder's scheduled job.
+type reminder struct {
+	ChannelID string
+	UserID    string
+	Message   string
+}
+
+func remind(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	duration, ok := bot.ParseDuration(ctx.Args["time"].StringValue())
+	if !ok || duration < time.Second {
+		response.Send(false, remindFail, "That isn't a valid amount of time, try something like 1h30m", 0)
+		return
+	}
+	message := ctx.Args["message"].StringValue()
+	if message == "" {
+		response.Send(false, remindFail, "You need to say what to be reminded about", 0)
+		return
+	}
+	data, err := json.Marshal(reminder{
+		ChannelID: ctx.ChannelID(),
+		UserID:    ctx.AuthorID(),
+		Message:   message,
+	})
+	if err != nil {
+		bot.Log.Errorf("unable to marshal reminder: %s", err)
+		response.Send(false, remindFail, "Unable to save the reminder", 0)
+		return
+	}
+	if _, err = bot.ScheduleAfter(duration, remindJob, string(data)); err != nil {
+		bot.Log.Errorf("unable to schedule reminder: %s", err)
+		response.Send(false, remindFail, "Unable to save the reminder", 0)
+		return
+	}
+	response.Send(true, "Reminder set", fmt.Sprintf("I'll remind you <t:%d:R>", time.Now().Add(duration).Unix()), 0)
+}
+
+// sendReminder
+// Sends a reminder to the channel it was set in, mentioning the user who set it.
+func sendReminder(data string) {
+	var r reminder
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		bot.Log.Errorf("unable to unmarshal reminder: %s", err)
+		return
+	}
+	_, err := bot.ReplyToUser(r.ChannelID, &discordgo.MessageSend{
+		Content: fmt.Sprintf("<@%s> Reminder: %s", r.UserID, r.Message),
+		AllowedMentions: &discordgo.MessageAllowedMentions{
+			Users: []string{r.UserID},
+		},
+	})
+	if err != nil {
+		bot.Log.Errorf("unable to send reminder to %s in %s: %s", r.UserID, r.ChannelID, err)
+	}
+}
+
+func init() {
+	bot.AddCommand(remindInfo, remind)
+	bot.AddSlashCommand(remindInfo)
+	bot.AddScheduledJob(remindJob, sendReminder)
+}
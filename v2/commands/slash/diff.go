@@ -0,0 +1,84 @@
+package slash
+
+import (
+	"sort"
+	"strings"
+
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// diff.go
+// Shows how the slash commands Discord has registered differ from the ones the bot defines
+
+var slashDiffInfo = bot.CreateCommandInfo("diff", "Compares registered slash commands with the ones the bot defines", false, bot.Utility)
+
+// maxFieldLength
+// The most characters Discord allows in an embed field's value.
+const maxFieldLength = 1024
+
+// fieldValue
+// Joins lines into a field value, cutting it short if it is over the field limit.
+func fieldValue(lines []string) string {
+	value := strings.Join(lines, "\n")
+	if len(value) > maxFieldLength {
+		cut := strings.LastIndex(value[:maxFieldLength-len("\n...")], "\n")
+		if cut < 0 {
+			cut = maxFieldLength - len("\n...")
+		}
+		value = value[:cut] + "\n..."
+	}
+	return value
+}
+
+func subCommandDiff(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	// Only bot admins can inspect slash commands
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response.Send(false, slashFail, "Sorry, only Bot Administrators can inspect slash commands!", 0)
+		return
+	}
+	diff, err := bot.DiffSlashCommands(ctx.Guild.ID)
+	if err != nil {
+		bot.Log.Errorf("unable to fetch slash commands: %s", err)
+		response.Send(false, slashFail, "Unable to fetch slash commands from Discord", 0)
+		return
+	}
+	if diff.Empty() {
+		response.Send(true, "Slash command diff", "Discord's slash commands match the ones the bot defines", 0)
+		return
+	}
+	if len(diff.Missing) > 0 {
+		response.AppendField(0, "Defined, but not registered:", fieldValue(quote(diff.Missing)), false)
+	}
+	if len(diff.Extra) > 0 {
+		response.AppendField(0, "Registered, but not defined:", fieldValue(quote(diff.Extra)), false)
+	}
+	if len(diff.Changed) > 0 {
+		names := make([]string, 0, len(diff.Changed))
+		for name := range diff.Changed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var lines []string
+		for _, name := range names {
+			lines = append(lines, "`"+name+"`: "+strings.Join(diff.Changed[name], "; "))
+		}
+		response.AppendField(0, "Registered with different options:", fieldValue(lines), false)
+	}
+	response.Send(true, "Slash command diff", "Registering slash commands again would resolve these", 0)
+}
+
+// quote
+// Wraps each name in backticks.
+func quote(names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "`" + name + "`"
+	}
+	return quoted
+}
+
+func init() {
+	slashDiffInfo.SetParent(false, "slash")
+	bot.AddChildCommand(slashDiffInfo, subCommandDiff)
+}
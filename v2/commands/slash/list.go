@@ -0,0 +1,178 @@
+package slash
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// list.go
+// Lists the slash commands Discord currently has registered for the bot, so drift can be spotted
+
+var slashListInfo = bot.CreateCommandInfo("list", "Lists the slash commands registered with Discord", false, bot.Utility)
+
+// pageSize
+// The amount of slash commands shown on a single page.
+const pageSize = 10
+
+// The custom IDs of the pagination buttons.
+const (
+	listPrevID = "slash:list:prev"
+	listNextID = "slash:list:next"
+)
+
+// commandTypes
+// Human-readable names for the application command types.
+var commandTypes = map[discordgo.ApplicationCommandType]string{
+	discordgo.ChatApplicationCommand:    "chat input",
+	discordgo.UserApplicationCommand:    "user",
+	discordgo.MessageApplicationCommand: "message",
+}
+
+// registeredCommands
+// Fetches the commands Discord has registered in the given guild, followed by the global commands
+// Each command is formatted as a single line.
+func registeredCommands(guildID string) ([]string, error) {
+	scopes := []string{""}
+	if guildID != "" {
+		scopes = []string{guildID, ""}
+	}
+	var lines []string
+	for _, scope := range scopes {
+		cmds, err := bot.API.ApplicationCommands(bot.Session.State.User.ID, scope)
+		if err != nil {
+			return nil, err
+		}
+		label := "global"
+		if scope != "" {
+			label = "guild"
+		}
+		for _, cmd := range cmds {
+			lines = append(lines, fmt.Sprintf("`%s` %s (%s, %s)", cmd.Name, cmd.ID, commandTypes[cmd.Type], label))
+		}
+	}
+	return lines, nil
+}
+
+// pageCount
+// Returns the amount of pages needed to display the given lines.
+func pageCount(lines []string) int {
+	if len(lines) == 0 {
+		return 1
+	}
+	return (len(lines) + pageSize - 1) / pageSize
+}
+
+// renderPage
+// Returns the description and footer text for a single page
+// Out of range pages wrap around, so the buttons can cycle through the list.
+func renderPage(lines []string, page int) (string, string) {
+	pages := pageCount(lines)
+	if len(lines) == 0 {
+		return "Discord has no slash commands registered", fmt.Sprintf("Page 1/%d", pages)
+	}
+	page = ((page % pages) + pages) % pages
+	start := page * pageSize
+	end := start + pageSize
+	if end > len(lines) {
+		end = len(lines)
+	}
+	return strings.Join(lines[start:end], "\n"), fmt.Sprintf("Page %d/%d", page+1, pages)
+}
+
+func subCommandList(ctx *bot.CmdContext) {
+	// Only bot admins can inspect slash commands
+	if !bot.IsAdmin(ctx.AuthorID()) {
+		response := bot.NewResponse(ctx, false, false, 0)
+		response.Send(false, slashFail, "Sorry, only Bot Administrators can inspect slash commands!", 0)
+		return
+	}
+	lines, err := registeredCommands(ctx.Guild.ID)
+	if err != nil {
+		bot.Log.Errorf("unable to fetch slash commands: %s", err)
+		response := bot.NewResponse(ctx, false, false, 0)
+		response.Send(false, slashFail, "Unable to fetch slash commands from Discord", 0)
+		return
+	}
+	// Only add the pagination buttons if there is more than one page
+	rows := 0
+	if pageCount(lines) > 1 {
+		rows = 1
+	}
+	response := bot.NewResponse(ctx, false, false, rows)
+	description, footer := renderPage(lines, 0)
+	response.AppendFooter(0, footer, "", false)
+	if rows > 0 {
+		response.AppendButton("Previous", discordgo.SecondaryButton, "", listPrevID, 0)
+		response.AppendButton("Next", discordgo.SecondaryButton, "", listNextID, 0)
+	}
+	response.Send(true, "Registered slash commands", description, 0)
+}
+
+// respondPrivately
+// Answers a button press with a message only the user who pressed it can see.
+func respondPrivately(ctx *bot.InteractionCtx, content string) {
+	err := bot.API.InteractionRespond(ctx.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		bot.Log.Errorf("error while responding to interaction: %s", err)
+	}
+}
+
+// handleListPage
+// Moves the list forwards or backwards a page, based on which button was pressed.
+func handleListPage(ctx *bot.InteractionCtx) {
+	if !bot.IsAdmin(ctx.InvokerID()) || ctx.Message == nil || len(ctx.Message.Embeds) == 0 {
+		respondPrivately(ctx, "Sorry, only Bot Administrators can inspect slash commands!")
+		return
+	}
+	embed := ctx.Message.Embeds[0]
+
+	// The current page is stored in the footer, which avoids keeping any state around
+	page := 1
+	if embed.Footer != nil {
+		var pages int
+		_, _ = fmt.Sscanf(embed.Footer.Text, "Page %d/%d", &page, &pages)
+	}
+	page--
+	if ctx.MessageComponentData().CustomID == listNextID {
+		page++
+	} else {
+		page--
+	}
+
+	lines, err := registeredCommands(ctx.GuildID)
+	if err != nil {
+		bot.Log.Errorf("unable to fetch slash commands: %s", err)
+		respondPrivately(ctx, "Unable to fetch slash commands from Discord")
+		return
+	}
+	description, footer := renderPage(lines, page)
+	embed.Description = description
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: footer}
+
+	err = bot.API.InteractionRespond(ctx.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     ctx.Message.Embeds,
+			Components: ctx.Message.Components,
+		},
+	})
+	if err != nil {
+		bot.Log.Errorf("error while responding to interaction: %s", err)
+	}
+}
+
+func init() {
+	slashListInfo.SetParent(false, "slash")
+	bot.AddChildCommand(slashListInfo, subCommandList)
+	bot.AddInteractHandler(&bot.InteractionInfo{Id: listPrevID}, handleListPage)
+	bot.AddInteractHandler(&bot.InteractionInfo{Id: listNextID}, handleListPage)
+}
@@ -0,0 +1,22 @@
+package slash
+
+import (
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// slash.go
+// The parent command for inspecting the state of slash command registration
+
+var slashFail = "Slash"
+
+var slashInfo = bot.CreateCommandInfo("slash", "Inspect slash command registration", false, bot.Utility)
+
+func slashCommand(ctx *bot.CmdContext) {
+	response := bot.NewResponse(ctx, false, false, 0)
+	response.Send(false, slashFail, "Invalid syntax, you did not enter a sub command", 0)
+}
+
+func init() {
+	slashInfo.SetParent(true, "")
+	bot.AddCommand(slashInfo, slashCommand)
+}
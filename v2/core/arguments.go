@@ -8,6 +8,8 @@ import (
 	"github.com/dlclark/regexp2"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // todo refactor
@@ -35,39 +37,66 @@ var (
 type ArgTypeGuards string
 
 var (
-	Int       ArgTypeGuards = "int"
-	String    ArgTypeGuards = "string"
-	Channel   ArgTypeGuards = "channel"
-	User      ArgTypeGuards = "user"
-	Role      ArgTypeGuards = "role"
-	GuildArg  ArgTypeGuards = "guild"
-	Message   ArgTypeGuards = "message"
-	Boolean   ArgTypeGuards = "bool"
-	Id        ArgTypeGuards = "id"
-	SubCmd    ArgTypeGuards = "subcmd"
-	SubCmdGrp ArgTypeGuards = "subcmdgrp"
-	ArrString ArgTypeGuards = "arrString"
-	Time      ArgTypeGuards = "time"
+	Int         ArgTypeGuards = "int"
+	String      ArgTypeGuards = "string"
+	Channel     ArgTypeGuards = "channel"
+	User        ArgTypeGuards = "user"
+	Role        ArgTypeGuards = "role"
+	GuildArg    ArgTypeGuards = "guild"
+	Message     ArgTypeGuards = "message"
+	Boolean     ArgTypeGuards = "bool"
+	Id          ArgTypeGuards = "id"
+	SubCmd      ArgTypeGuards = "subcmd"
+	SubCmdGrp   ArgTypeGuards = "subcmdgrp"
+	ArrString   ArgTypeGuards = "arrString"
+	Time        ArgTypeGuards = "time"
+	Color       ArgTypeGuards = "color"
+	Emoji       ArgTypeGuards = "emoji"
+	Schedule    ArgTypeGuards = "schedule"    // A HH:MM time of day or a five field cron expression, see ParseSchedule
+	MessageLink ArgTypeGuards = "messagelink" // A link to a message, see ParseMessageLink
+	URL         ArgTypeGuards = "url"         // An absolute http or https link, see ParseURL
 )
 
 // ArgInfo
 // Describes a CommandInfo argument.
 type ArgInfo struct {
-	Match         ArgTypes
-	TypeGuard     ArgTypeGuards
-	Description   string
-	Required      bool
-	Flag          bool
-	DefaultOption string
-	Choices       []string
-	Regex         *regexp2.Regexp
+	Match            ArgTypes
+	TypeGuard        ArgTypeGuards
+	Description      string
+	Required         bool
+	Flag             bool
+	DefaultOption    string
+	Choices          []string
+	Aliases          []string                          // Extra names a flag arg will also accept (e.g: -u for --user)
+	MinLength        int                               // The fewest characters a string value can have; zero means no minimum
+	MaxLength        int                               // The most characters a string value can have; zero means no maximum
+	ChoicesFunc      func() []ArgChoice                // Slash command choices that are only known at registration time, see SetChoicesFunc
+	ChoiceList       []ArgChoice                       // Slash command choices with their own values or translated names, see AddLocalizedChoices
+	FuzzyMember      bool                              // If a User arg that isn't a mention or ID is looked up by member name, see SetFuzzyMember
+	Transform        func(string) (interface{}, error) // Normalizes the value once it has been parsed, see SetTransform
+	FromReply        bool                              // If a User arg that isn't given is filled with the author of the replied-to message, see SetFromReply
+	Repeat           int                               // How many values a repeated arg takes, see AddRepeatedArg; zero for a single value
+	ChannelTypes     []discordgo.ChannelType           // The types of channel a Channel arg accepts; empty accepts any, see SetChannelTypes
+	DefaultToInvoker bool                              // If a User arg that isn't given is the user who ran the command, see SetDefaultToInvoker
+	AllowedHosts     []string                          // The hosts a URL arg can link to, or their subdomains; empty allows any, see SetAllowedHosts
+	Regex            *regexp2.Regexp
+}
+
+// ArgChoice
+// A single slash command choice, with a value that can differ from the name shown to the user.
+type ArgChoice struct {
+	Name              string
+	Value             interface{}
+	NameLocalizations map[discordgo.Locale]string // The name shown to users with these locales; Name is shown to everyone else
 }
 
 // CommandArg
 // Describes what a cmd ctx will receive.
 type CommandArg struct {
-	info  ArgInfo
-	Value interface{}
+	info     ArgInfo
+	Value    interface{}
+	resolved interface{} // The user, member, role or channel Discord resolved for a slash command option
+	focused  bool        // Whether the user is typing in this option, during autocomplete
 }
 
 // Arguments
@@ -133,20 +162,73 @@ func (cI *CommandInfo) AddArg(argument string, typeGuard ArgTypeGuards, match Ar
 	return cI
 }
 
+// maxRepeat
+// The most values a repeated arg can take, since Discord allows at most 25 options on a slash command.
+const maxRepeat = 25
+
+// AddRepeatedArg
+// Adds an arg that takes up to max values, which the command gets as a []string, see ListValue.
+// Slash commands can't take lists, so the arg is registered as the numbered options name1 through nameN,
+// which are optional apart from name1 on a required arg. Message commands take the values from the rest of the content,
+// split on spaces. max is capped at 25, but the numbered options count towards the command's limit of 25 options too,
+// and they must come last on a required arg, since Discord doesn't allow required options after optional ones.
+func (cI *CommandInfo) AddRepeatedArg(argument string, typeGuard ArgTypeGuards, description string, required bool, max int) *CommandInfo {
+	if max > maxRepeat {
+		Log.Warningf("Repeated argument %s on command %s takes %d values, capping it at %d", argument, cI.Trigger, max, maxRepeat)
+		max = maxRepeat
+	}
+	if max < 1 {
+		max = 1
+	}
+	cI.Arguments.Set(argument, &ArgInfo{
+		TypeGuard:   typeGuard,
+		Description: description,
+		Required:    required,
+		Match:       ArgContent,
+		Repeat:      max,
+	})
+	return cI
+}
+
+// collapseRepeatedArgs
+// Gathers the values of each repeated arg into a single []string under the arg's name
+// Slash command values come from the numbered options, which are removed, and message command values are split from the content.
+func collapseRepeatedArgs(args Arguments, infoArgs *orderedmap.OrderedMap) {
+	if infoArgs == nil {
+		return
+	}
+	for _, k := range infoArgs.Keys() {
+		v, _ := infoArgs.Get(k)
+		vv := v.(*ArgInfo)
+		if vv.Repeat == 0 {
+			continue
+		}
+		var values []string
+		for i := 1; i <= vv.Repeat; i++ {
+			name := k + strconv.Itoa(i)
+			if arg, ok := args[name]; ok {
+				values = append(values, fmt.Sprint(arg.Value))
+				delete(args, name)
+			}
+		}
+		if arg, ok := args[k]; ok && len(values) == 0 {
+			if str, ok := arg.Value.(string); ok && str != "" {
+				values = strings.Fields(str)
+			}
+		}
+		if len(values) > vv.Repeat {
+			values = values[:vv.Repeat]
+		}
+		args[k] = CommandArg{info: *vv, Value: values}
+	}
+}
+
 // AddFlagArg
 // Adds a flag arg, which is a special type of argument
 // This type of argument allows for the user to place the "phrase" (e.g: --debug) anywhere
 // in the command string and the parser will find it.
 func (cI *CommandInfo) AddFlagArg(flag string, typeGuard ArgTypeGuards, match ArgTypes, description string, required bool, defaultOption string) *CommandInfo {
-	regexString := flag
-	if match == ArgOption {
-		// Currently, it only supports a limited character set.
-		// todo figure out how to detect any character
-		regexString = fmt.Sprintf("--%s (([a-zA-Z0-9:/.]+)|(\"[a-zA-Z0-9:/. ]+\"))", flag)
-	} else {
-		regexString = fmt.Sprintf("--%s", flag)
-	}
-	regex, err := regexp2.Compile(regexString, 0)
+	regex, err := createFlagRegex(flag, nil, match)
 	if err != nil {
 		Log.Fatalf("Unable to create regex for flag on command %s flag: %s", cI.Trigger, flag)
 	}
@@ -162,6 +244,30 @@ func (cI *CommandInfo) AddFlagArg(flag string, typeGuard ArgTypeGuards, match Ar
 	return cI
 }
 
+// AddArgAlias
+// Adds extra names that a flag arg will accept. Single character aliases use a single dash (e.g: -u),
+// longer aliases use two (e.g: --member). The parsed value is always stored under the original arg name.
+func (cI *CommandInfo) AddArgAlias(arg string, aliases []string) *CommandInfo {
+	v, ok := cI.Arguments.Get(arg)
+	if !ok {
+		Log.Errorf("Unable to get argument %s in AddArgAlias", arg)
+		return cI
+	}
+	vv := v.(*ArgInfo)
+	if !vv.Flag {
+		Log.Errorf("Argument %s on command %s is not a flag arg, aliases are ignored", arg, cI.Trigger)
+		return cI
+	}
+	regex, err := createFlagRegex(arg, aliases, vv.Match)
+	if err != nil {
+		Log.Fatalf("Unable to create regex for flag on command %s flag: %s", cI.Trigger, arg)
+	}
+	vv.Aliases = aliases
+	vv.Regex = regex
+	cI.Arguments.Set(arg, vv)
+	return cI
+}
+
 // AddChoices
 // Adds SubCmd choices.
 func (cI *CommandInfo) AddChoices(arg string, choices []string) *CommandInfo {
@@ -177,11 +283,227 @@ func (cI *CommandInfo) AddChoices(arg string, choices []string) *CommandInfo {
 	return cI
 }
 
+// AddLocalizedChoices
+// Adds slash command choices that can have a value that differs from their name, and names translated per locale.
+// These are added after any choices from AddChoices.
+func (cI *CommandInfo) AddLocalizedChoices(arg string, choices []ArgChoice) *CommandInfo {
+	v, ok := cI.Arguments.Get(arg)
+	if !ok {
+		Log.Errorf("Unable to get argument %s in AddLocalizedChoices", arg)
+		return cI
+	}
+	vv := v.(*ArgInfo)
+	vv.ChoiceList = append(vv.ChoiceList, choices...)
+	cI.Arguments.Set(arg, vv)
+	return cI
+}
+
+// SetChoicesFunc
+// Sets a function that provides an arg's slash command choices. It is called every time slash commands
+// are registered, so the choices can come from config that changes between deployments.
+// These are added after any static choices from AddChoices and AddLocalizedChoices.
+func (cI *CommandInfo) SetChoicesFunc(arg string, choicesFunc func() []ArgChoice) *CommandInfo {
+	v, ok := cI.Arguments.Get(arg)
+	if !ok {
+		Log.Errorf("Unable to get argument %s in SetChoicesFunc", arg)
+		return cI
+	}
+	vv := v.(*ArgInfo)
+	vv.ChoicesFunc = choicesFunc
+	cI.Arguments.Set(arg, vv)
+	return cI
+}
+
+// SetFuzzyMember
+// Lets a User arg on a message command be given as a username or nickname, instead of a mention or ID.
+// The name is looked up in the guild's members, and the command is refused if nobody or more than one member matches.
+func (cI *CommandInfo) SetFuzzyMember(arg string) *CommandInfo {
+	v, ok := cI.Arguments.Get(arg)
+	if !ok {
+		Log.Errorf("Unable to get argument %s in SetFuzzyMember", arg)
+		return cI
+	}
+	vv := v.(*ArgInfo)
+	if vv.TypeGuard != User {
+		Log.Errorf("Argument %s on command %s is not a User arg, fuzzy matching is ignored", arg, cI.Trigger)
+		return cI
+	}
+	vv.FuzzyMember = true
+	cI.Arguments.Set(arg, vv)
+	return cI
+}
+
+// SetFromReply
+// Lets a User arg be left out when the command is sent as a reply, using the replied-to message's author instead
+// (e.g: replying to a message with !warn). Without a reply the arg is needed as usual.
+func (cI *CommandInfo) SetFromReply(arg string) *CommandInfo {
+	v, ok := cI.Arguments.Get(arg)
+	if !ok {
+		Log.Errorf("Unable to get argument %s in SetFromReply", arg)
+		return cI
+	}
+	vv := v.(*ArgInfo)
+	if vv.TypeGuard != User {
+		Log.Errorf("Argument %s on command %s is not a User arg, filling it from replies is ignored", arg, cI.Trigger)
+		return cI
+	}
+	vv.FromReply = true
+	cI.Arguments.Set(arg, vv)
+	return cI
+}
+
+// SetChannelTypes
+// Restricts a Channel arg to the given types of channel (e.g: discordgo.ChannelTypeGuildVoice)
+// Slash commands only offer channels of those types, and message commands given any other channel aren't run.
+func (cI *CommandInfo) SetChannelTypes(arg string, types ...discordgo.ChannelType) *CommandInfo {
+	v, ok := cI.Arguments.Get(arg)
+	if !ok {
+		Log.Errorf("Unable to get argument %s in SetChannelTypes", arg)
+		return cI
+	}
+	vv := v.(*ArgInfo)
+	if vv.TypeGuard != Channel {
+		Log.Errorf("Argument %s on command %s is not a Channel arg, channel types are ignored", arg, cI.Trigger)
+		return cI
+	}
+	vv.ChannelTypes = types
+	cI.Arguments.Set(arg, vv)
+	return cI
+}
+
+// checkChannelTypes
+// Makes sure every Channel arg that was given is one of the channel types it accepts.
+func checkChannelTypes(args Arguments, infoArgs *orderedmap.OrderedMap) error {
+	if infoArgs == nil {
+		return nil
+	}
+	for _, k := range infoArgs.Keys() {
+		v, _ := infoArgs.Get(k)
+		vv := v.(*ArgInfo)
+		if len(vv.ChannelTypes) == 0 {
+			continue
+		}
+		arg, ok := args[k]
+		if !ok || arg.StringValue() == "" {
+			continue
+		}
+		channel, ok := arg.resolved.(*discordgo.Channel)
+		if !ok || channel == nil {
+			var err error
+			if channel, err = lookupChannel(CleanID(arg.StringValue())); err != nil {
+				return fmt.Errorf("%s must be a channel", k)
+			}
+		}
+		if !channelTypeAllowed(channel.Type, vv.ChannelTypes) {
+			return fmt.Errorf("%s can't be that type of channel", k)
+		}
+	}
+	return nil
+}
+
+// channelTypeAllowed
+// Check if a channel type is in the list of allowed types.
+func channelTypeAllowed(channelType discordgo.ChannelType, allowed []discordgo.ChannelType) bool {
+	for _, t := range allowed {
+		if t == channelType {
+			return true
+		}
+	}
+	return false
+}
+
+// SetDefaultToInvoker
+// Makes a User arg that isn't given resolve to the user who ran the command (e.g: !avatar shows your own)
+// The arg is registered as optional with slash commands, since it always has a value. Replies are used first
+// for args that are also filled from replies, see SetFromReply.
+func (cI *CommandInfo) SetDefaultToInvoker(arg string) *CommandInfo {
+	v, ok := cI.Arguments.Get(arg)
+	if !ok {
+		Log.Errorf("Unable to get argument %s in SetDefaultToInvoker", arg)
+		return cI
+	}
+	vv := v.(*ArgInfo)
+	if vv.TypeGuard != User {
+		Log.Errorf("Argument %s on command %s is not a User arg, defaulting it to the invoker is ignored", arg, cI.Trigger)
+		return cI
+	}
+	vv.DefaultToInvoker = true
+	cI.Arguments.Set(arg, vv)
+	return cI
+}
+
+// SetTransform
+// Sets a function that normalizes an arg's value (e.g: trimming or lowercasing it) before the command runs.
+// The function gets the value as a string, including default values, and what it returns becomes the value.
+// If it returns an error, the command isn't run and the user is told their arguments are invalid.
+func (cI *CommandInfo) SetTransform(arg string, transform func(string) (interface{}, error)) *CommandInfo {
+	v, ok := cI.Arguments.Get(arg)
+	if !ok {
+		Log.Errorf("Unable to get argument %s in SetTransform", arg)
+		return cI
+	}
+	vv := v.(*ArgInfo)
+	vv.Transform = transform
+	cI.Arguments.Set(arg, vv)
+	return cI
+}
+
+// SetArgLength
+// Limits how many characters a string arg can be. A limit of zero means no limit on that side.
+// Values outside the limits are refused before the command runs, and slash commands pass the limits on to Discord.
+func (cI *CommandInfo) SetArgLength(arg string, minLength int, maxLength int) *CommandInfo {
+	v, ok := cI.Arguments.Get(arg)
+	if !ok {
+		Log.Errorf("Unable to get argument %s in SetArgLength", arg)
+		return cI
+	}
+	vv := v.(*ArgInfo)
+	vv.MinLength = minLength
+	vv.MaxLength = maxLength
+	cI.Arguments.Set(arg, vv)
+	return cI
+}
+
 func (cI *CommandInfo) SetTyping(isTyping bool) *CommandInfo {
 	cI.IsTyping = isTyping
 	return cI
 }
 
+// SetMaxConcurrent
+// Limits how many invocations of the command can run at once. Zero means unlimited.
+func (cI *CommandInfo) SetMaxConcurrent(max int) *CommandInfo {
+	cI.MaxConcurrent = max
+	return cI
+}
+
+// SetCooldown
+// Sets how long a user has to wait between uses of the command.
+func (cI *CommandInfo) SetCooldown(cooldown time.Duration) *CommandInfo {
+	cI.Cooldown = cooldown
+	return cI
+}
+
+// SetGuildIDs
+// Limits the command to the given guilds. Its slash command is registered in each of them, and never globally.
+func (cI *CommandInfo) SetGuildIDs(guildIDs ...string) *CommandInfo {
+	cI.GuildIDs = guildIDs
+	return cI
+}
+
+// SetExperimental
+// Marks the command as experimental, so only bot admins and the guild's beta testers can use it, see SetBetaRole.
+func (cI *CommandInfo) SetExperimental(experimental bool) *CommandInfo {
+	cI.Experimental = experimental
+	return cI
+}
+
+// SetCooldownScope
+// Sets who shares the command's cooldown, see CooldownScope. The default is each user on their own.
+func (cI *CommandInfo) SetCooldownScope(scope CooldownScope) *CommandInfo {
+	cI.CooldownScope = scope
+	return cI
+}
+
 //todo subcommand stuff
 //// BindToChoice
 //// Bind an arg to choice (subcmd)
@@ -190,15 +512,25 @@ func (cI *CommandInfo) SetTyping(isTyping bool) *CommandInfo {
 //}
 
 // CreateAppOptSt
-// Creates an ApplicationOptionsStruct for all the args.
+// Creates an ApplicationOptionsStruct for all the args, describing the command as a sub command.
 func (cI *CommandInfo) CreateAppOptSt() *discordgo.ApplicationCommandOption {
-	return &discordgo.ApplicationCommandOption{}
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommand,
+		Name:        strings.ToLower(cI.Trigger),
+		Description: commandDescription(cI),
+		Options:     createOptions(cI),
+	}
 }
 
 // -- Argument Parser --
 
 // ParseArguments
 // Version two of the argument parser.
+// Args are found in this order: flag args (see AddFlagArg) anywhere in the string, then optional args given
+// by name in any order (e.g: --channel #general, or just --silent for a bool), then the rest by position.
+// Required args are always positional. An optional arg given by name is skipped when filling args by position,
+// so if it is given both ways the named value wins, and the positional value fills the next arg instead.
+// Length limits (see SetArgLength) are checked on the result before the command runs, so the invoker can be told why.
 func ParseArguments(args string, infoArgs *orderedmap.OrderedMap) *Arguments {
 	ar := make(Arguments)
 
@@ -214,6 +546,8 @@ func ParseArguments(args string, infoArgs *orderedmap.OrderedMap) *Arguments {
 	var modK []string
 	// First find all flags in the string.
 	splitString, ar, modK := findAllFlags(args, k, infoArgs, &ar)
+	// Then find the optional args that were given by name.
+	splitString, modK = findNamedArgs(splitString, modK, infoArgs, &ar)
 	// Find all the option args (e.g. single 'phrases' or quoted strings)
 	// Then return the currentPos, so we can index k and find remaining keys.
 	// Also return a modified Arguments struct
@@ -239,8 +573,276 @@ func ParseArguments(args string, infoArgs *orderedmap.OrderedMap) *Arguments {
 	}
 }
 
+// resolveReplyArgs
+// Fills in FromReply args that weren't given with the author of the message the command replied to.
+// A required FromReply arg that is still empty, because the command wasn't a reply, is an error.
+func resolveReplyArgs(ctx *CmdContext) error {
+	if ctx.Interaction != nil || ctx.Cmd.Arguments == nil {
+		return nil
+	}
+	for _, k := range ctx.Cmd.Arguments.Keys() {
+		v, _ := ctx.Cmd.Arguments.Get(k)
+		vv := v.(*ArgInfo)
+		if !vv.FromReply || ctx.Args[k].StringValue() != "" {
+			continue
+		}
+		replied, err := ctx.RepliedMessage()
+		if err != nil {
+			Log.Errorf("unable to get the message replied to by %s: %s", ctx.Message.ID, err)
+		}
+		if replied == nil || replied.Author == nil {
+			if vv.Required && !vv.DefaultToInvoker {
+				return fmt.Errorf("%s is required, or reply to one of their messages", k)
+			}
+			continue
+		}
+		ctx.Args[k] = CommandArg{info: *vv, Value: replied.Author.ID}
+	}
+	return nil
+}
+
+// resolveInvokerArgs
+// Fills in DefaultToInvoker args that weren't given with the user who ran the command, along with their member in guilds.
+func resolveInvokerArgs(ctx *CmdContext) {
+	if ctx.Cmd.Arguments == nil {
+		return
+	}
+	for _, k := range ctx.Cmd.Arguments.Keys() {
+		v, _ := ctx.Cmd.Arguments.Get(k)
+		vv := v.(*ArgInfo)
+		if !vv.DefaultToInvoker || ctx.Args[k].StringValue() != "" {
+			continue
+		}
+		userID := ctx.AuthorID()
+		if userID == "" {
+			continue
+		}
+		ctx.Args[k] = CommandArg{info: *vv, Value: userID, resolved: invoker(ctx)}
+	}
+}
+
+// invoker
+// Returns the member who ran the command, with their user, or just the user outside of guilds.
+func invoker(ctx *CmdContext) interface{} {
+	var member *discordgo.Member
+	var user *discordgo.User
+	if ctx.Interaction != nil {
+		member, user = ctx.Interaction.Member, ctx.Interaction.User
+		if member != nil {
+			user = member.User
+		}
+	} else if ctx.Message != nil {
+		member, user = ctx.Message.Member, ctx.Message.Author
+	}
+	if user == nil {
+		return nil
+	}
+	if member != nil {
+		// Message members don't include their user
+		m := *member
+		m.User = user
+		m.GuildID = ctx.GuildID()
+		return &m
+	}
+	return user
+}
+
+// applyTransforms
+// Runs each arg's Transform on its value. Args that weren't given get their default value transformed,
+// since slash commands leave them out instead of filling them in.
+func applyTransforms(args Arguments, infoArgs *orderedmap.OrderedMap) error {
+	if infoArgs == nil {
+		return nil
+	}
+	for _, k := range infoArgs.Keys() {
+		v, _ := infoArgs.Get(k)
+		vv := v.(*ArgInfo)
+		if vv.Transform == nil {
+			continue
+		}
+		arg, ok := args[k]
+		if !ok {
+			if vv.DefaultOption == "" {
+				continue
+			}
+			arg = CommandArg{info: *vv, Value: vv.DefaultOption}
+		}
+		// Slash command values can be numbers or bools, which the transform gets as text
+		raw, ok := arg.Value.(string)
+		if !ok {
+			raw = fmt.Sprint(arg.Value)
+		}
+		transformed, err := vv.Transform(raw)
+		if err != nil {
+			return fmt.Errorf("%s %s", k, err)
+		}
+		arg.Value = transformed
+		args[k] = arg
+	}
+	return nil
+}
+
+// checkArgLengths
+// Checks every parsed string value against its arg's MinLength and MaxLength.
+// Returns an error describing the first arg that is out of bounds.
+func checkArgLengths(args Arguments, infoArgs *orderedmap.OrderedMap) error {
+	if infoArgs == nil {
+		return nil
+	}
+	for _, k := range infoArgs.Keys() {
+		v, _ := infoArgs.Get(k)
+		vv := v.(*ArgInfo)
+		if vv.MinLength == 0 && vv.MaxLength == 0 {
+			continue
+		}
+		arg, ok := args[k]
+		if !ok {
+			continue
+		}
+		str, ok := arg.Value.(string)
+		if !ok {
+			continue
+		}
+		length := utf8.RuneCountInString(str)
+		if vv.MaxLength > 0 && length > vv.MaxLength {
+			return fmt.Errorf("%s can't be longer than %d characters", k, vv.MaxLength)
+		}
+		if length < vv.MinLength {
+			return fmt.Errorf("%s must be at least %d characters", k, vv.MinLength)
+		}
+	}
+	return nil
+}
+
+// argValidators
+// Checks the values of args of each type, returning the value normalized into the form commands get.
+// The parser keeps invalid values of these types instead of dropping them, see findInvalidValue,
+// so checkArgValues can reject them before the command runs.
+var argValidators = map[ArgTypeGuards]func(value string, info *ArgInfo) (string, error){
+	Color:       validateColor,
+	Emoji:       validateEmoji,
+	Schedule:    validateSchedule,
+	MessageLink: validateMessageLink,
+	URL:         validateURL,
+}
+
+// checkArgValues
+// Checks every parsed arg with a validator in argValidators, and stores the normalized value.
+// Slash commands skip the parser, so this is where their values are checked too. Values that aren't strings,
+// like a color given as a number, are left as they are.
+// Returns an error describing the first invalid arg.
+func checkArgValues(args Arguments, infoArgs *orderedmap.OrderedMap) error {
+	if infoArgs == nil {
+		return nil
+	}
+	for _, k := range infoArgs.Keys() {
+		v, _ := infoArgs.Get(k)
+		vv := v.(*ArgInfo)
+		validate, ok := argValidators[vv.TypeGuard]
+		if !ok {
+			continue
+		}
+		arg, ok := args[k]
+		if !ok {
+			continue
+		}
+		value, isString := arg.Value.(string)
+		if !isString || value == "" {
+			continue
+		}
+		normalized, err := validate(value, vv)
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		arg.Value = normalized
+		args[k] = arg
+	}
+	return nil
+}
+
 /* Argument Parsing Helpers */
 
+// createFlagRegex
+// Compiles the regex used to find a flag arg, matching the flag or any of its aliases.
+func createFlagRegex(flag string, aliases []string, match ArgTypes) (*regexp2.Regexp, error) {
+	names := []string{"--" + regexp2.Escape(flag)}
+	for _, alias := range aliases {
+		if len(alias) == 1 {
+			names = append(names, "-"+regexp2.Escape(alias))
+		} else {
+			names = append(names, "--"+regexp2.Escape(alias))
+		}
+	}
+	// The lookarounds stop an alias like -u from matching part of --user
+	regexString := fmt.Sprintf("(?<!\\S)(?:%s)(?!\\S)", strings.Join(names, "|"))
+	if match == ArgOption {
+		// Currently, it only supports a limited character set.
+		// todo figure out how to detect any character
+		regexString += " (([a-zA-Z0-9:/.]+)|(\"[a-zA-Z0-9:/. ]+\"))"
+	}
+	return regexp2.Compile(regexString, 0)
+}
+
+// findNamedArgs
+// Finds optional option args that were given by name (e.g: --channel #general)
+// A bool arg given by name without a value is set to true.
+// Named args are removed from the split string and the keys, so positional parsing only sees what is left.
+func findNamedArgs(splitString []string, keys []string, infoArgs *orderedmap.OrderedMap, args *Arguments) ([]string, []string) {
+	if len(keys) == 0 || len(splitString) == 0 {
+		return splitString, keys
+	}
+	var remaining []string
+	for i := 0; i < len(splitString); i++ {
+		name, vv, ok := namedArg(splitString[i], keys, infoArgs)
+		if !ok {
+			remaining = append(remaining, splitString[i])
+			continue
+		}
+		value := vv.DefaultOption
+		// Invalid values of validated types are kept, so the command is rejected instead of running without them
+		if i+1 < len(splitString) && !isNamedArg(splitString[i+1], keys, infoArgs) && (checkTypeGuard(splitString[i+1], vv.TypeGuard) || rejectsInvalid(vv.TypeGuard)) {
+			value = splitString[i+1]
+			i++
+		} else if vv.TypeGuard == Boolean {
+			value = "true"
+		}
+		(*args)[name] = handleArgOption(value, *vv)
+		keys = RemoveItem(keys, name)
+	}
+	return remaining, keys
+}
+
+// namedArg
+// Checks if a phrase names an optional option arg, returning the arg's name and info if so.
+func namedArg(phrase string, keys []string, infoArgs *orderedmap.OrderedMap) (string, *ArgInfo, bool) {
+	if !strings.HasPrefix(phrase, "--") {
+		return "", nil, false
+	}
+	name := strings.TrimPrefix(phrase, "--")
+	for _, k := range keys {
+		if k != name {
+			continue
+		}
+		v, ok := infoArgs.Get(k)
+		if !ok {
+			return "", nil, false
+		}
+		vv := v.(*ArgInfo)
+		if vv.Required || vv.Flag || vv.Match != ArgOption {
+			return "", nil, false
+		}
+		return name, vv, true
+	}
+	return "", nil, false
+}
+
+// isNamedArg
+// Checks if a phrase names an optional option arg.
+func isNamedArg(phrase string, keys []string, infoArgs *orderedmap.OrderedMap) bool {
+	_, _, ok := namedArg(phrase, keys, infoArgs)
+	return ok
+}
+
 func createContentString(splitString []string, currentPos int) (string, int) {
 	str := ""
 	for i := currentPos; i < len(splitString); i++ {
@@ -272,16 +874,19 @@ func findAllOptionArgs(argString []string, keys []string, infoArgs *orderedmap.O
 		}
 		vv := iA.(*ArgInfo)
 		if vv.Match == ArgContent {
+			// The content is everything after the args that have already been found
 			modKeys = RemoveItems(keys, indexes)
-			return *args, true, argString, modKeys
+			return *args, true, argString[currentPos:], modKeys
 		}
 		if vv.Required {
 			if vv.TypeGuard != String {
 				var value string
 				value, argString = findTypeGuard(strings.Join(argString, " "), argString, vv.TypeGuard)
+				value, argString = findFuzzyMember(value, argString, currentPos, vv)
+				value, argString = findInvalidValue(value, argString, currentPos, vv)
 				(*args)[v] = handleArgOption(value, *vv)
 				indexes = append(indexes, i)
-			} else if checkTypeGuard(argString[currentPos], vv.TypeGuard) {
+			} else if currentPos < len(argString) && checkTypeGuard(argString[currentPos], vv.TypeGuard) {
 				(*args)[v] = handleArgOption(argString[currentPos], *vv)
 				currentPos++
 				indexes = append(indexes, i)
@@ -333,6 +938,8 @@ func findAllOptionArgs(argString []string, keys []string, infoArgs *orderedmap.O
 		if vv.TypeGuard != String {
 			var value string
 			value, argString = findTypeGuard(strings.Join(argString, " "), argString, vv.TypeGuard)
+			value, argString = findFuzzyMember(value, argString, currentPos, vv)
+			value, argString = findInvalidValue(value, argString, currentPos, vv)
 			(*args)[v] = handleArgOption(value, *vv)
 			indexes = append(indexes, i)
 		} else if checkTypeGuard(argString[currentPos], vv.TypeGuard) {
@@ -347,62 +954,128 @@ func findAllOptionArgs(argString []string, keys []string, infoArgs *orderedmap.O
 	return *args, false, createSplitString(modifiedArgString), modKeys
 }
 
+// findFuzzyMember
+// If a fuzzy User arg had no mention or ID, takes the phrase at pos as a member name instead.
+// The name is resolved to a member just before the command runs, see resolveFuzzyMembers.
+func findFuzzyMember(value string, argString []string, pos int, info *ArgInfo) (string, []string) {
+	if value != "" || !info.FuzzyMember || info.TypeGuard != User || pos >= len(argString) {
+		return value, argString
+	}
+	value = argString[pos]
+	return value, append(argString[:pos:pos], argString[pos+1:]...)
+}
+
+// findInvalidValue
+// If an arg that is validated before the command runs had no valid value, takes the phrase at pos as its value,
+// so the command is rejected with an error instead of running with the arg left empty. See checkArgValues.
+// This means an optional arg of these types always takes the phrase at its position, if there is one.
+func findInvalidValue(value string, argString []string, pos int, info *ArgInfo) (string, []string) {
+	if value != "" || pos >= len(argString) || !rejectsInvalid(info.TypeGuard) {
+		return value, argString
+	}
+	value = argString[pos]
+	return value, append(argString[:pos:pos], argString[pos+1:]...)
+}
+
+// rejectsInvalid
+// Check if args of a type are validated before the command runs, so an invalid value is kept to be rejected.
+func rejectsInvalid(typeguard ArgTypeGuards) bool {
+	_, ok := argValidators[typeguard]
+	return ok
+}
+
 func findTypeGuard(input string, array []string, typeguard ArgTypeGuards) (string, []string) {
-	return "", []string{}
-	//switch typeguard {
-	//case Int:
-	//	if match, isMatch := TypeGuard["int"].FindStringMatch(input); isMatch == nil && match != nil {
-	//		return match.String(), RemoveItem(array, match.String())
-	//	}
-	//	return "", array
-	//case Boolean:
-	//	if match, isMatch := TypeGuard["boolean"].FindStringMatch(input); isMatch == nil && match != nil {
-	//		return match.String(), RemoveItem(array, match.String())
-	//	}
-	//	return "", array
-	//case Channel:
-	//	if match, isMatch := MentionStringRegexes["channel"].FindStringMatch(input); isMatch == nil && match != nil {
-	//		return match.String(), RemoveItem(array, match.String())
-	//	} else if match, isMatch := MentionStringRegexes["id"].FindStringMatch(input); isMatch == nil && match != nil {
-	//		return match.String(), RemoveItem(array, match.String())
-	//	}
-	//	return "", array
-	//case Role:
-	//	if match, isMatch := MentionStringRegexes["role"].FindStringMatch(input); isMatch == nil && match != nil {
-	//		return match.String(), RemoveItem(array, match.String())
-	//	} else if match, isMatch := MentionStringRegexes["id"].FindStringMatch(input); isMatch == nil && match != nil {
-	//		return match.String(), RemoveItem(array, match.String())
-	//	}
-	//	return "", array
-	//case User:
-	//	if match, isMatch := MentionStringRegexes["user"].FindStringMatch(input); isMatch == nil && match != nil {
-	//		return match.String(), RemoveItem(array, match.String())
-	//	} else if match, isMatch := MentionStringRegexes["id"].FindStringMatch(input); isMatch == nil && match != nil {
-	//		return match.String(), RemoveItem(array, match.String())
-	//	}
-	//	return "", array
-	//case ArrString:
-	//	if match, isMatch := TypeGuard["arrString"].FindStringMatch(input); isMatch == nil && match != nil {
-	//		return match.String(), RemoveItem(array, match.String())
-	//	}
-	//	return "", array
-	//case Message:
-	//	if match, isMatch := TypeGuard["message_url"].FindStringMatch(input); isMatch == nil && match != nil {
-	//		return match.String(), RemoveItem(array, match.String())
-	//	}
-	//	return "", array
-	//case Time:
-	//	match := strings.Join(FindAllString(TimeRegexes["all"], input), "")
-	//	//if match, isMatch := TimeRegexes["all"].Mat(input); isMatch == nil && match != nil {
-	//	//	return match.String(), RemoveItem(array, match.String())
-	//	//}
-	//	if match != "" {
-	//		return match, RemoveItem(array, match)
-	//	}
-	//	return "", array
-	//default:
-	//	return "", array
-	//}
+	switch typeguard {
+	case Int:
+		if match, isMatch := TypeGuard["int"].FindStringMatch(input); isMatch == nil && match != nil {
+			return match.String(), RemoveItem(array, match.String())
+		}
+		return "", array
+	case Boolean:
+		if match, isMatch := TypeGuard["boolean"].FindStringMatch(input); isMatch == nil && match != nil {
+			return match.String(), RemoveItem(array, match.String())
+		}
+		return "", array
+	case Channel:
+		if match, isMatch := MentionStringRegexes["channel"].FindStringMatch(input); isMatch == nil && match != nil {
+			return match.String(), RemoveItem(array, match.String())
+		} else if match, isMatch := MentionStringRegexes["id"].FindStringMatch(input); isMatch == nil && match != nil {
+			return match.String(), RemoveItem(array, match.String())
+		}
+		return "", array
+	case Role:
+		if match, isMatch := MentionStringRegexes["role"].FindStringMatch(input); isMatch == nil && match != nil {
+			return match.String(), RemoveItem(array, match.String())
+		} else if match, isMatch := MentionStringRegexes["id"].FindStringMatch(input); isMatch == nil && match != nil {
+			return match.String(), RemoveItem(array, match.String())
+		}
+		return "", array
+	case User:
+		if match, isMatch := MentionStringRegexes["user"].FindStringMatch(input); isMatch == nil && match != nil {
+			return match.String(), RemoveItem(array, match.String())
+		} else if match, isMatch := MentionStringRegexes["id"].FindStringMatch(input); isMatch == nil && match != nil {
+			return match.String(), RemoveItem(array, match.String())
+		}
+		return "", array
+	case Message:
+		if match, isMatch := TypeGuard["message_url"].FindStringMatch(input); isMatch == nil && match != nil {
+			return match.String(), RemoveItem(array, match.String())
+		}
+		return "", array
+	case Color:
+		for _, v := range array {
+			if _, ok := ParseColor(v); ok {
+				return v, RemoveItem(array, v)
+			}
+		}
+		return "", array
+	case Emoji:
+		for _, v := range array {
+			if _, ok := ParseEmoji(v); ok {
+				return v, RemoveItem(array, v)
+			}
+		}
+		return "", array
+	case Schedule:
+		// Cron expressions are five words when they aren't quoted, so those are looked for before single words
+		for i := 0; i+cronFields <= len(array); i++ {
+			v := strings.Join(array[i:i+cronFields], " ")
+			if _, _, err := ParseSchedule(v); err == nil {
+				return v, append(array[:i:i], array[i+cronFields:]...)
+			}
+		}
+		for _, v := range array {
+			if _, _, err := ParseSchedule(v); err == nil {
+				return v, RemoveItem(array, v)
+			}
+		}
+		return "", array
+	case MessageLink:
+		for _, v := range array {
+			if _, err := ParseMessageLink(v); err == nil {
+				return v, RemoveItem(array, v)
+			}
+		}
+		return "", array
+	case URL:
+		for _, v := range array {
+			if _, err := ParseURL(v, nil); err == nil {
+				return v, RemoveItem(array, v)
+			}
+		}
+		return "", array
+	case Time:
+		match := strings.Join(FindAllString(TimeRegexes["all"], input), "")
+		//if match, isMatch := TimeRegexes["all"].Mat(input); isMatch == nil && match != nil {
+		//	return match.String(), RemoveItem(array, match.String())
+		//}
+		if match != "" {
+			return match, RemoveItem(array, match)
+		}
+		return "", array
+	default:
+		return "", array
+	}
 }
 
 func findAllFlags(argString string, keys []string, infoArgs *orderedmap.OrderedMap, args *Arguments) ([]string, Arguments, []string) {
@@ -501,6 +1174,13 @@ func createSplitString(argString string) []string {
 }
 
 func handleArgOption(str string, info ArgInfo) CommandArg {
+	// Validated values are normalized, so commands don't need to know which format was used
+	// Invalid values are kept as they are, to be rejected by checkArgValues
+	if validate, ok := argValidators[info.TypeGuard]; ok && str != "" {
+		if normalized, err := validate(str, &info); err == nil {
+			str = normalized
+		}
+	}
 	return CommandArg{
 		info:  info,
 		Value: str,
@@ -508,67 +1188,115 @@ func handleArgOption(str string, info ArgInfo) CommandArg {
 }
 
 func checkTypeGuard(str string, typeguard ArgTypeGuards) bool {
-	//switch typeguard {
-	//case String:
-	//	return true
-	//case Int:
-	//	if _, err := strconv.Atoi(str); err == nil {
-	//		return true
-	//	}
-	//	return false
-	//case Boolean:
-	//	if _, err := strconv.ParseBool(str); err == nil {
-	//		return true
-	//	}
-	//case Channel:
-	//	if isMatch, _ := MentionStringRegexes["channel"].MatchString(str); isMatch {
-	//		return true
-	//	} else if isMatch, _ := MentionStringRegexes["id"].MatchString(str); isMatch {
-	//		return true
-	//	}
-	//case Role:
-	//	if isMatch, _ := MentionStringRegexes["role"].MatchString(str); isMatch {
-	//		return true
-	//	} else if isMatch, _ := MentionStringRegexes["id"].MatchString(str); isMatch {
-	//		return true
-	//	}
-	//case User:
-	//	if isMatch, _ := MentionStringRegexes["user"].MatchString(str); isMatch {
-	//		return true
-	//	} else if isMatch, _ := MentionStringRegexes["id"].MatchString(str); isMatch {
-	//		return true
-	//	}
-	//	return false
-	//case ArrString:
-	//	if isMatch, _ := TypeGuard["arrString"].MatchString(str); isMatch {
-	//		return true
-	//	}
-	//	return false
-	//case Message:
-	//	if isMatch, _ := TypeGuard["message_url"].MatchString(str); isMatch {
-	//		return true
-	//	}
-	//	return false
-	//}
+	switch typeguard {
+	case String, ArrString:
+		return true
+	case Int:
+		if _, err := strconv.Atoi(str); err == nil {
+			return true
+		}
+		return false
+	case Boolean:
+		if _, err := strconv.ParseBool(str); err == nil {
+			return true
+		}
+	case Channel:
+		if isMatch, _ := MentionStringRegexes["channel"].MatchString(str); isMatch {
+			return true
+		} else if isMatch, _ := MentionStringRegexes["id"].MatchString(str); isMatch {
+			return true
+		}
+	case Role:
+		if isMatch, _ := MentionStringRegexes["role"].MatchString(str); isMatch {
+			return true
+		} else if isMatch, _ := MentionStringRegexes["id"].MatchString(str); isMatch {
+			return true
+		}
+	case User:
+		if isMatch, _ := MentionStringRegexes["user"].MatchString(str); isMatch {
+			return true
+		} else if isMatch, _ := MentionStringRegexes["id"].MatchString(str); isMatch {
+			return true
+		}
+		return false
+	case Message:
+		if isMatch, _ := TypeGuard["message_url"].MatchString(str); isMatch {
+			return true
+		}
+		return false
+	case Id, GuildArg:
+		isMatch, _ := TypeGuard["id"].MatchString(str)
+		return isMatch
+	case Time:
+		isMatch, _ := TypeGuard["time"].MatchString(str)
+		return isMatch
+	case Color:
+		_, ok := ParseColor(str)
+		return ok
+	case Emoji:
+		_, ok := ParseEmoji(str)
+		return ok
+	case Schedule:
+		_, _, err := ParseSchedule(str)
+		return err == nil
+	case MessageLink:
+		_, err := ParseMessageLink(str)
+		return err == nil
+	case URL:
+		_, err := ParseURL(str, nil)
+		return err == nil
+	}
 	return false
 }
-
 /* Argument Casting s*/
 
+// Focused
+// Returns the option the user is currently typing in during autocomplete, and whether there is one
+// Discord focuses exactly one option in an autocomplete interaction, and none otherwise.
+func (args Arguments) Focused() (string, CommandArg, bool) {
+	for name, arg := range args {
+		if arg.focused {
+			return name, arg, true
+		}
+	}
+	return "", CommandArg{}, false
+}
+
+// IsFocused
+// Returns true if the user is typing in this option, during autocomplete.
+func (ag CommandArg) IsFocused() bool {
+	return ag.focused
+}
+
 // StringValue
-// Returns the string value of the arg.
+// Returns the string value of the arg. Values of other types, e.g: from a Transform, are formatted with fmt.
 func (ag CommandArg) StringValue() string {
-	if ag.Value == nil {
+	switch v := ag.Value.(type) {
+	case nil:
 		return ""
-	}
-	if v, ok := ag.Value.(string); ok {
+	case string:
 		return v
-	} else if v := strconv.FormatFloat(ag.Value.(float64), 'f', 2, 64); v != "" {
-		return v
-	} else if v = strconv.FormatBool(ag.Value.(bool)); v != "" {
+	case []string:
+		return strings.Join(v, " ")
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ListValue
+// Returns the values of a repeated arg, see AddRepeatedArg. Any other arg is returned as a single value, or none if it is empty.
+func (ag CommandArg) ListValue() []string {
+	if v, ok := ag.Value.([]string); ok {
 		return v
 	}
-	return ""
+	if v := ag.StringValue(); v != "" {
+		return []string{v}
+	}
+	return nil
 }
 
 // Int64Value
@@ -626,9 +1354,41 @@ func (ag CommandArg) BoolValue() bool {
 	return false
 }
 
+// ColorValue
+// Returns the color value of the arg as an int, ready to be used in an embed or role.
+// Slash commands pass colors through as they were typed, so they are parsed here as well.
+func (ag CommandArg) ColorValue() int {
+	if ag.Value == nil {
+		return 0
+	}
+	if v, ok := ag.Value.(float64); ok {
+		return int(v)
+	}
+	color, _ := ParseColor(ag.StringValue())
+	return color
+}
+
+// EmojiValue
+// Returns the emoji value of the arg, in the format discordgo expects for reactions.
+// Slash commands pass emoji through as they were typed, so they are parsed here as well.
+func (ag CommandArg) EmojiValue() string {
+	emoji, _ := ParseEmoji(ag.StringValue())
+	return emoji
+}
+
+// DurationValue
+// Returns the value of the arg as a duration, see ParseDuration. Invalid durations are zero.
+func (ag CommandArg) DurationValue() time.Duration {
+	duration, _ := ParseDuration(ag.StringValue())
+	return duration
+}
+
 // ChannelValue is a utility function for casting value to a channel struct
 // Returns a channel struct, partial channel struct, or a nil value.
 func (ag CommandArg) ChannelValue(s *discordgo.Session) (*discordgo.Channel, error) {
+	if ch, ok := ag.resolved.(*discordgo.Channel); ok && ch != nil {
+		return ch, nil
+	}
 	chanID := ag.StringValue()
 	if chanID == "" {
 		return &discordgo.Channel{ID: chanID}, errors.New("no channel id")
@@ -656,6 +1416,12 @@ func (ag CommandArg) ChannelValue(s *discordgo.Session) (*discordgo.Channel, err
 // MemberValue is a utility function for casting value to a member struct
 // Returns a user struct, partial user struct, or a nil value.
 func (ag CommandArg) MemberValue(s *discordgo.Session, g string) (*discordgo.Member, error) {
+	if m, ok := ag.resolved.(*discordgo.Member); ok && m != nil {
+		if m.GuildID == "" {
+			m.GuildID = g
+		}
+		return m, nil
+	}
 	userID := ag.StringValue()
 	if userID == "" {
 		return &discordgo.Member{
@@ -682,18 +1448,14 @@ func (ag CommandArg) MemberValue(s *discordgo.Session, g string) (*discordgo.Mem
 			},
 		}, errors.New("session is nil")
 	}
-	u, err := s.State.Member(g, cleanedId)
-
+	u, err := ResolveMember(g, cleanedId)
 	if err != nil {
-		u, err = s.GuildMember(g, cleanedId)
-		if err != nil {
-			return &discordgo.Member{
-				GuildID: g,
-				User: &discordgo.User{
-					ID: userID,
-				},
-			}, errors.New("cant find user")
-		}
+		return &discordgo.Member{
+			GuildID: g,
+			User: &discordgo.User{
+				ID: userID,
+			},
+		}, errors.New("cant find user")
 	}
 	return u, nil
 }
@@ -701,6 +1463,16 @@ func (ag CommandArg) MemberValue(s *discordgo.Session, g string) (*discordgo.Mem
 // UserValue is a utility function for casting value to a member struct
 // Returns a user struct, partial user struct, or a nil value.
 func (ag CommandArg) UserValue(s *discordgo.Session) (*discordgo.User, error) {
+	switch resolved := ag.resolved.(type) {
+	case *discordgo.User:
+		if resolved != nil {
+			return resolved, nil
+		}
+	case *discordgo.Member:
+		if resolved != nil && resolved.User != nil {
+			return resolved.User, nil
+		}
+	}
 	userID := ag.StringValue()
 	if userID == "" {
 		return &discordgo.User{
@@ -718,8 +1490,7 @@ func (ag CommandArg) UserValue(s *discordgo.Session) (*discordgo.User, error) {
 			ID: userID,
 		}, errors.New("session is nil")
 	}
-	u, err := s.User(cleanedId)
-
+	u, err := ResolveUser(cleanedId)
 	if err != nil {
 		return &discordgo.User{
 
@@ -732,6 +1503,9 @@ func (ag CommandArg) UserValue(s *discordgo.Session) (*discordgo.User, error) {
 // RoleValue is a utility function for casting value to a user struct
 // Returns a user struct, partial user struct, or a nil value.
 func (ag CommandArg) RoleValue(s *discordgo.Session, gID string) (*discordgo.Role, error) {
+	if r, ok := ag.resolved.(*discordgo.Role); ok && r != nil {
+		return r, nil
+	}
 	roleID := ag.StringValue()
 	if roleID == "" {
 		return nil, errors.New("unable to find roleid")
@@ -0,0 +1,113 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// attachments.go
+// This file contains helpers for commands that take their input either as an argument or as an attached file
+
+// maxAttachmentRead
+// The most bytes read from an attachment used as command input.
+const maxAttachmentRead = 1 << 20
+
+// ErrNoInput
+// Returned by ArgOrAttachment when the argument is empty and there is no text attachment to read instead.
+var ErrNoInput = errors.New("no argument or text attachment was given")
+
+// ErrAttachmentTooLarge
+// Returned by ArgOrAttachment when the attachment is over the read limit.
+var ErrAttachmentTooLarge = errors.New("the attachment is too large")
+
+// attachmentClient
+// The client attachments are downloaded with.
+var attachmentClient = &http.Client{Timeout: 10 * time.Second}
+
+// ArgOrAttachment
+// Returns the named argument if it was given, otherwise the contents of the first text file attached to the command.
+// Attachments over 1 MiB aren't read, and an error wrapping ErrAttachmentTooLarge is returned instead.
+func (ctx *CmdContext) ArgOrAttachment(name string) (string, error) {
+	if arg, ok := ctx.Args[name]; ok {
+		if value := arg.StringValue(); value != "" {
+			return value, nil
+		}
+	}
+	for _, attachment := range ctx.attachments() {
+		if !isTextAttachment(attachment) {
+			continue
+		}
+		return readAttachment(attachment)
+	}
+	return "", ErrNoInput
+}
+
+// attachments
+// Returns the files attached to the command, in the order they were attached.
+func (ctx *CmdContext) attachments() []*discordgo.MessageAttachment {
+	if ctx.Interaction == nil {
+		if ctx.Message == nil {
+			return nil
+		}
+		return ctx.Message.Attachments
+	}
+	data, ok := ctx.Interaction.Data.(discordgo.ApplicationCommandInteractionData)
+	if !ok || data.Resolved == nil {
+		return nil
+	}
+	attachments := make([]*discordgo.MessageAttachment, 0, len(data.Resolved.Attachments))
+	for _, attachment := range data.Resolved.Attachments {
+		attachments = append(attachments, attachment)
+	}
+	// Snowflakes of the same length sort in the order they were created
+	sort.Slice(attachments, func(i, j int) bool {
+		a, b := attachments[i].ID, attachments[j].ID
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+	return attachments
+}
+
+// isTextAttachment
+// Check if an attachment looks like text. Attachments without a content type are read, and checked once downloaded.
+func isTextAttachment(attachment *discordgo.MessageAttachment) bool {
+	contentType := strings.ToLower(attachment.ContentType)
+	return contentType == "" || strings.HasPrefix(contentType, "text/") || strings.HasPrefix(contentType, "application/json")
+}
+
+// readAttachment
+// Downloads a text attachment, refusing anything larger than maxAttachmentRead.
+func readAttachment(attachment *discordgo.MessageAttachment) (string, error) {
+	if attachment.Size > maxAttachmentRead {
+		return "", fmt.Errorf("%w: %s is %d bytes, but the limit is %d", ErrAttachmentTooLarge, attachment.Filename, attachment.Size, maxAttachmentRead)
+	}
+	resp, err := attachmentClient.Get(attachment.URL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New("unable to download attachment: " + resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAttachmentRead+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxAttachmentRead {
+		return "", fmt.Errorf("%w: %s is over the limit of %d bytes", ErrAttachmentTooLarge, attachment.Filename, maxAttachmentRead)
+	}
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("%s is not a text file", attachment.Filename)
+	}
+	return string(data), nil
+}
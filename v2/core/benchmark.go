@@ -0,0 +1,109 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// benchmark.go
+// This file contains the synthetic inputs used to time the argument parsers, shared by benchmark_test.go and the benchmark command
+
+// ParserTiming
+// How long one of the parsers took on its synthetic input.
+type ParserTiming struct {
+	Name  string        // What was timed
+	Runs  int           // How many times it was run
+	Total time.Duration // How long all the runs took
+}
+
+// PerRun
+// The average time a single run took.
+func (t ParserTiming) PerRun() time.Duration {
+	if t.Runs == 0 {
+		return 0
+	}
+	return t.Total / time.Duration(t.Runs)
+}
+
+// benchCommandInfo
+// A command with a mix of option, content and flag args, like most real commands.
+func benchCommandInfo() *CommandInfo {
+	return CreateCommandInfo("bench", "A synthetic command for benchmarks", true, Utility).
+		AddArg("user", User, ArgOption, "A user", true, "").
+		AddArg("count", Int, ArgOption, "A number", false, "1").
+		AddFlagArg("color", Color, ArgOption, "A color", false, "").
+		AddFlagArg("silent", Boolean, ArgOption, "A switch", false, "false").
+		AddArg("reason", String, ArgContent, "Some text", false, "")
+}
+
+// benchArgString
+// Input for benchCommandInfo that fills in every arg.
+const benchArgString = `<@123456789012345678> 5 --color #ff00ff --silent true being "very" rude in the general channel, again`
+
+// benchInteractionOptions
+// Options as a slash command with nested subcommands would send them.
+func benchInteractionOptions() []*discordgo.ApplicationCommandInteractionDataOption {
+	leaf := make([]*discordgo.ApplicationCommandInteractionDataOption, 0, 10)
+	for i := 0; i < 10; i++ {
+		leaf = append(leaf, &discordgo.ApplicationCommandInteractionDataOption{
+			Name:  fmt.Sprintf("option%d", i),
+			Type:  discordgo.ApplicationCommandOptionString,
+			Value: fmt.Sprintf("value %d", i),
+		})
+	}
+	return []*discordgo.ApplicationCommandInteractionDataOption{{
+		Name: "group",
+		Type: discordgo.ApplicationCommandOptionSubCommand,
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+			Name:    "sub",
+			Type:    discordgo.ApplicationCommandOptionSubCommand,
+			Options: leaf,
+		}},
+	}}
+}
+
+// benchChildCommands
+// A parent command with n children, to build a subcommand struct from.
+func benchChildCommands(n int) (*CommandInfo, map[string]Command) {
+	parent := CreateCommandInfo("benchparent", "A synthetic parent for benchmarks", true, Utility)
+	parent.SetParent(true, "")
+	children := make(map[string]Command, n)
+	for i := 0; i < n; i++ {
+		child := benchCommandInfo()
+		child.Trigger = fmt.Sprintf("child%d", i)
+		child.SetParent(false, parent.Trigger)
+		children[child.Trigger] = Command{Info: *child}
+	}
+	return parent, children
+}
+
+// timeParser
+// Runs fn the given number of times, returning how long it took.
+func timeParser(name string, runs int, fn func()) ParserTiming {
+	start := time.Now()
+	for i := 0; i < runs; i++ {
+		fn()
+	}
+	return ParserTiming{Name: name, Runs: runs, Total: time.Since(start)}
+}
+
+// BenchmarkParsers
+// Times ParseArguments, ParseInteractionArgs and subcommand struct creation on synthetic input, each run the given number of times.
+func BenchmarkParsers(runs int) []ParserTiming {
+	info := benchCommandInfo()
+	options := benchInteractionOptions()
+	parent, children := benchChildCommands(25)
+	return []ParserTiming{
+		timeParser("ParseArguments", runs, func() {
+			ParseArguments(benchArgString, info.Arguments)
+		}),
+		timeParser("ParseInteractionArgs", runs, func() {
+			ParseInteractionArgs(options)
+		}),
+		timeParser("createChatInputSubCmdStruct (25 children)", runs, func() {
+			createChatInputSubCmdStruct(parent, children)
+		}),
+	}
+}
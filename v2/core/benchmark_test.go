@@ -0,0 +1,29 @@
+package core
+
+import (
+	"testing"
+)
+
+func BenchmarkParseArguments(b *testing.B) {
+	info := benchCommandInfo()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ParseArguments(benchArgString, info.Arguments)
+	}
+}
+
+func BenchmarkParseInteractionArgs(b *testing.B) {
+	options := benchInteractionOptions()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ParseInteractionArgs(options)
+	}
+}
+
+func BenchmarkCreateChatInputSubCmdStruct(b *testing.B) {
+	parent, children := benchChildCommands(25)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		createChatInputSubCmdStruct(parent, children)
+	}
+}
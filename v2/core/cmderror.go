@@ -0,0 +1,45 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// cmderror.go
+// This file contains the adapter for command functions that return an error, which is then shown to the user who ran the command
+
+// ErrBotFunction
+// A command function that returns an error. Wrap it with WithError to use it as a BotFunction.
+type ErrBotFunction func(ctx *CmdContext) error
+
+// WithError
+// Adapts a function that returns an error into a BotFunction. A returned error is shown to the user who ran
+// the command, privately for slash commands, instead of only in an admin error report. Panics are still reported as usual.
+func WithError(fn ErrBotFunction) BotFunction {
+	return func(ctx *CmdContext) {
+		err := fn(ctx)
+		if err == nil {
+			return
+		}
+		Log.Debugf("Command %s returned an error: %s", ctx.Cmd.Trigger, err)
+		if sendErr := ctx.ShowError(err); sendErr != nil {
+			Log.Errorf("unable to show the error from %s to the user: %s", ctx.Cmd.Trigger, sendErr)
+		}
+	}
+}
+
+// ShowError
+// Shows an error to the user who ran the command. Slash commands get a message only that user can see,
+// unless the command deferred publicly and hasn't responded yet, in which case the deferred message shows it instead.
+func (ctx *CmdContext) ShowError(err error) error {
+	content := ctx.Translate(MsgCommandFailed, err.Error())
+	if ctx.Interaction == nil {
+		return ctx.Reply(content)
+	}
+	sendErr := ctx.Followup(content, true)
+	if errors.Is(sendErr, ErrDeferPending) {
+		return ctx.sendReply(&discordgo.MessageSend{Content: content}, true)
+	}
+	return sendErr
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// colors.go
+// This file contains the named colors accepted by Color args, and the parser that normalizes them
+
+// NamedColors
+// The color names a Color arg accepts, case-insensitively.
+var NamedColors = map[string]int{
+	"default":  0x000000,
+	"black":    0x000000,
+	"white":    0xFFFFFF,
+	"grey":     0x95A5A6,
+	"gray":     0x95A5A6,
+	"darkgrey": 0x607D8B,
+	"darkgray": 0x607D8B,
+	"red":      0xE74C3C,
+	"darkred":  0x992D22,
+	"orange":   0xE67E22,
+	"gold":     0xF1C40F,
+	"yellow":   0xFEE75C,
+	"green":    0x2ECC71,
+	"teal":     0x1ABC9C,
+	"aqua":     0x1ABC9C,
+	"blue":     0x3498DB,
+	"navy":     0x34495E,
+	"purple":   0x9B59B6,
+	"magenta":  0xE91E63,
+	"pink":     0xEB459E,
+	"fuchsia":  0xEB459E,
+	"blurple":  0x5865F2,
+	"success":  ColorSuccess,
+	"failure":  ColorFailure,
+}
+
+// maxColor
+// The largest color Discord accepts (#FFFFFF).
+const maxColor = 0xFFFFFF
+
+// ParseColor
+// Parses a color given as #RRGGBB, 0xRRGGBB, a named color, or a decimal int.
+// Returns the color as an int, and whether the input was a valid color.
+func ParseColor(in string) (int, bool) {
+	in = strings.ToLower(strings.TrimSpace(in))
+	if in == "" {
+		return 0, false
+	}
+	if color, ok := NamedColors[strings.ReplaceAll(in, " ", "")]; ok {
+		return color, true
+	}
+
+	var color int64
+	var err error
+	switch {
+	case strings.HasPrefix(in, "#"):
+		if len(in) != 7 {
+			return 0, false
+		}
+		color, err = strconv.ParseInt(in[1:], 16, 32)
+	case strings.HasPrefix(in, "0x"):
+		color, err = strconv.ParseInt(in[2:], 16, 32)
+	default:
+		color, err = strconv.ParseInt(in, 10, 32)
+	}
+	if err != nil || color < 0 || color > maxColor {
+		return 0, false
+	}
+	return int(color), true
+}
+
+// validateColor
+// The argValidators entry for Color args, which normalizes the color into a decimal int, so commands can rely on ColorValue.
+func validateColor(value string, _ *ArgInfo) (string, error) {
+	color, ok := ParseColor(value)
+	if !ok {
+		return "", fmt.Errorf("%q is not a color, try a name like red, or a hex code like #FF0000", value)
+	}
+	return strconv.Itoa(color), nil
+}
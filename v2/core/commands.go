@@ -1,9 +1,12 @@
 package core
 
 import (
-	"runtime"
+	"errors"
+	"fmt"
 	"runtime/debug"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/QPixel/orderedmap"
@@ -24,27 +27,169 @@ const (
 // CommandInfo
 // The definition of a command's info. This is everything about the command, besides the function it will run.
 type CommandInfo struct {
-	Aliases     []string               // Aliases for the normal trigger
-	Arguments   *orderedmap.OrderedMap // Arguments for the command
-	Description string                 // A short description of what the command does
-	Group       string                 // The group this command belongs to
-	ParentID    string                 // The ID of the parent command
-	Public      bool                   // Whether non-admins and non-mods can use this command
-	IsTyping    bool                   // Whether the command will show a typing thing when ran.
-	IsParent    bool                   // If the command is the parent of a subcommand tree
-	IsChild     bool                   // If the command is the child
-	Trigger     string                 // The string that will trigger the command
+	Aliases        []string               // Aliases for the normal trigger
+	Arguments      *orderedmap.OrderedMap // Arguments for the command
+	Description    string                 // A short description of what the command does
+	Group          string                 // The group this command belongs to
+	ParentID       string                 // The ID of the parent command
+	Public         bool                   // Whether non-admins and non-mods can use this command
+	IsTyping       bool                   // Whether the command will show a typing thing when ran.
+	IsParent       bool                   // If the command is the parent of a subcommand tree
+	IsChild        bool                   // If the command is the child
+	Trigger        string                 // The string that will trigger the command
+	MaxConcurrent  int                    // How many invocations of the command can run at once; zero is unlimited
+	OwnerGuildOnly bool                   // If the command can only be used in the owner guild; children follow their parent
+	Feature        string                 // If set, the command only exists in guilds where this feature is enabled
+	GuildIDs       []string               // If set, the command only exists in these guilds, and its slash command is never registered globally
+	AllowBots      bool                   // If other bots and webhooks can run the command; they are ignored by default
+	SlashOnly      bool                   // If the command can only be used as a slash command
+	MessageOnly    bool                   // If the command can only be used as a message command, so it is never added as a slash command
+	Serialized     bool                   // If invocations of the command in the same guild wait for each other, instead of running at once
+	Cooldown       time.Duration          // How long a user has to wait between uses of the command; zero is no cooldown
+	CooldownScope  CooldownScope          // Who shares the cooldown: each user (the default), each channel, or each guild
+	AutoAck        bool                   // If slash command invocations are deferred before the command runs, so they can't time out
+	ResponseTTL    time.Duration          // How long the command's responses stay up before they are deleted; zero is never
+	Experimental   bool                   // If only bot admins and the guild's beta testers can use the command, see SetBetaRole. Its slash command is still listed for everyone in guilds with beta testers
 }
 
 // CmdContext
 // This is a context of a single command invocation
 // This gives the command function access to all the information it might need.
 type CmdContext struct {
-	Guild       *Guild // NOTE: Guild is a pointer, since we want to use the SAME instance of the guild across the program!
-	Cmd         CommandInfo
-	Args        Arguments
-	Message     *discordgo.Message // Technically deprecated, but still useful for message commands
-	Interaction *discordgo.Interaction
+	Guild        *Guild // NOTE: Guild is a pointer, since we want to use the SAME instance of the guild across the program!
+	Cmd          CommandInfo
+	Args         Arguments
+	Message      *discordgo.Message // Technically deprecated, but still useful for message commands; prefer AuthorID, ChannelID and GuildID
+	Interaction  *discordgo.Interaction
+	ArgString    string                         // The raw text after the trigger, or after the subcommand if one matched; message commands only
+	Subcommand   string                         // The trigger of the subcommand that matched, empty if a parent runs without one
+	mentions     []discordgo.AllowedMentionType // Mention types allowed on top of user mentions, see AllowMentions
+	channel      *discordgo.Channel             // The channel the command was run in, once it has been looked up, see Channel
+	replied      *discordgo.Message             // The message the command replied to, once it has been looked up, see RepliedMessage
+	acknowledged bool                           // If the interaction was already deferred for the command, see CommandInfo.AutoAck
+	deferPending bool                           // If the interaction was deferred, and the deferred message hasn't been replaced yet
+}
+
+// AuthorID
+// Returns the ID of the user who ran the command, whether it was run from a message or a slash command.
+func (ctx *CmdContext) AuthorID() string {
+	if ctx.Interaction != nil {
+		if ctx.Interaction.Member != nil && ctx.Interaction.Member.User != nil {
+			return ctx.Interaction.Member.User.ID
+		}
+		if ctx.Interaction.User != nil {
+			return ctx.Interaction.User.ID
+		}
+	}
+	if ctx.Message != nil && ctx.Message.Author != nil {
+		return ctx.Message.Author.ID
+	}
+	return ""
+}
+
+// ChannelID
+// Returns the ID of the channel the command was run in, whether it was run from a message or a slash command.
+func (ctx *CmdContext) ChannelID() string {
+	if ctx.Interaction != nil {
+		return ctx.Interaction.ChannelID
+	}
+	if ctx.Message != nil {
+		return ctx.Message.ChannelID
+	}
+	return ""
+}
+
+// GuildID
+// Returns the ID of the guild the command was run in, or an empty string in DMs.
+func (ctx *CmdContext) GuildID() string {
+	if ctx.Interaction != nil {
+		return ctx.Interaction.GuildID
+	}
+	if ctx.Message != nil {
+		return ctx.Message.GuildID
+	}
+	return ""
+}
+
+// Channel
+// Returns the channel the command was run in, from the state, or from the API if the state doesn't have it.
+// In a thread this is the thread itself, whose ParentID is the channel it was started in.
+// The channel is kept on the context, so it is only looked up once.
+func (ctx *CmdContext) Channel() (*discordgo.Channel, error) {
+	if ctx.channel != nil {
+		return ctx.channel, nil
+	}
+	channelID := ctx.ChannelID()
+	if channelID == "" {
+		return nil, errors.New("the command was not run in a channel")
+	}
+	channel, err := lookupChannel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	ctx.channel = channel
+	return channel, nil
+}
+
+// RepliedMessage
+// Returns the message that the command's message replied to, or nil if it wasn't a reply.
+// Discord usually includes the message, but if it doesn't, it is looked up and kept on the context.
+func (ctx *CmdContext) RepliedMessage() (*discordgo.Message, error) {
+	if ctx.replied != nil {
+		return ctx.replied, nil
+	}
+	if ctx.Interaction != nil || ctx.Message == nil || ctx.Message.MessageReference == nil {
+		return nil, nil
+	}
+	if ctx.Message.ReferencedMessage != nil {
+		ctx.replied = ctx.Message.ReferencedMessage
+		return ctx.replied, nil
+	}
+	reference := ctx.Message.MessageReference
+	channelID := reference.ChannelID
+	if channelID == "" {
+		channelID = ctx.Message.ChannelID
+	}
+	message, err := API.ChannelMessage(channelID, reference.MessageID)
+	if err != nil {
+		return nil, err
+	}
+	ctx.replied = message
+	return message, nil
+}
+
+// lookupChannel
+// Looks up a channel in the state, falling back to the API for channels the state doesn't have, like archived threads.
+func lookupChannel(channelID string) (*discordgo.Channel, error) {
+	if channel, err := Session.State.Channel(channelID); err == nil {
+		return channel, nil
+	}
+	return API.Channel(channelID)
+}
+
+// AllowMentions
+// Lets this command's responses ping roles, or @everyone and @here, which are blocked by default
+// so commands that echo user input can't be used to mass ping.
+func (ctx *CmdContext) AllowMentions(types ...discordgo.AllowedMentionType) {
+	ctx.mentions = append(ctx.mentions, types...)
+}
+
+// allowedMentions
+// Returns the allowed mentions for this command's responses. User mentions are always allowed,
+// but replies never ping the user being replied to.
+func (ctx *CmdContext) allowedMentions() *discordgo.MessageAllowedMentions {
+	parse := []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeUsers}
+	if ctx != nil {
+		for _, t := range ctx.mentions {
+			if !internal.Contains(parse, t) {
+				parse = append(parse, t)
+			}
+		}
+	}
+	return &discordgo.MessageAllowedMentions{
+		Parse:       parse,
+		RepliedUser: false,
+	}
 }
 
 // BotFunction
@@ -85,13 +230,43 @@ var childCommands = make(ChildCommand)
 // A map of aliases to command triggers.
 var commandAliases = make(map[string]string)
 
+// phraseAliases
+// The aliases made up of more than one word, keyed by their first word and sorted longest first
+// This way only the phrases starting with a message's first word are checked, see matchPhraseAlias.
+var phraseAliases = make(map[string][]string)
+
 // slashCommands
 // All the registered core commands that are also slash commands
 // This is also private so other commands cannot modify it.
 var slashCommands = make(map[string]discordgo.ApplicationCommand)
 
-// commandsGC.
-var commandsGC = 0
+// slashGroup
+// A virtual slash command parent, which only exists to group other commands as its sub commands.
+type slashGroup struct {
+	Description string
+	Triggers    []string
+}
+
+// slashGroups
+// All the slash groups, keyed by the name of the top level slash command.
+var slashGroups = make(map[string]slashGroup)
+
+// commandSemaphores
+// Buffered channels that limit how many invocations of a command can run at once, keyed by commandKey
+// These are only created while commands are being added, so they are read-only once the bot is running.
+var commandSemaphores = make(map[string]chan struct{})
+
+// serialLocks
+// The mutexes that Serialized commands hold while they run, keyed by guild ID and commandKey.
+var serialLocks = make(map[string]*sync.Mutex)
+
+// serialLocksLock
+// Guards serialLocks, since the mutexes are created the first time a command is run in a guild.
+var serialLocksLock sync.Mutex
+
+// unknownCommandHandler
+// Run when a message has a trigger that doesn't match any command, set with SetUnknownCommandHandler.
+var unknownCommandHandler BotFunction
 
 // AddCommand
 // Add a command to the bot.
@@ -110,10 +285,77 @@ func AddCommand(info *CommandInfo, function BotFunction) {
 			continue
 		}
 		alias = strings.ToLower(alias)
+		// Multi-word aliases are matched word by word, so the spacing they were added with doesn't matter
+		if words := strings.Fields(alias); len(words) > 1 {
+			alias = strings.Join(words, " ")
+			addPhraseAlias(words[0], alias)
+		}
 		commandAliases[alias] = info.Trigger
 	}
 	// Add the command to the map; command triggers are case-insensitive
 	commands[strings.ToLower(info.Trigger)] = command
+	addCommandSemaphore(command.Info)
+}
+
+// addPhraseAlias
+// Indexes a multi-word alias by its first word, keeping the longest phrases first so matching is greedy.
+func addPhraseAlias(first string, phrase string) {
+	phrases := append(phraseAliases[first], phrase)
+	sort.SliceStable(phrases, func(i, j int) bool {
+		return len(strings.Fields(phrases[i])) > len(strings.Fields(phrases[j]))
+	})
+	phraseAliases[first] = phrases
+}
+
+// matchPhraseAlias
+// Returns the longest multi-word alias the given words start with, and how many words it uses.
+// Zero words are used if no phrase matches.
+func matchPhraseAlias(words []string) (string, int) {
+	if len(words) < 2 {
+		return "", 0
+	}
+	for _, phrase := range phraseAliases[strings.ToLower(words[0])] {
+		phraseWords := strings.Fields(phrase)
+		if len(phraseWords) > len(words) {
+			continue
+		}
+		matched := true
+		for i, word := range phraseWords {
+			if !strings.EqualFold(word, words[i]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return phrase, len(phraseWords)
+		}
+	}
+	return "", 0
+}
+
+// AddLazyCommand
+// Add a command to the bot whose function is only built when the command is first run, by calling loader.
+// The function is kept after that, so loader is called once. Otherwise this is the same as AddCommand.
+func AddLazyCommand(info *CommandInfo, loader func() BotFunction) {
+	AddCommand(info, lazyFunction(info.Trigger, loader))
+}
+
+// lazyFunction
+// Wraps a loader in a BotFunction that loads the real function on its first run, and runs it.
+func lazyFunction(trigger string, loader func() BotFunction) BotFunction {
+	var once sync.Once
+	var function BotFunction
+	return func(ctx *CmdContext) {
+		once.Do(func() {
+			function = loader()
+			if function == nil {
+				Log.Errorf("The loader for command %s returned no function", trigger)
+			}
+		})
+		if function != nil {
+			function(ctx)
+		}
+	}
 }
 
 // AddChildCommand
@@ -128,21 +370,35 @@ func AddChildCommand(info *CommandInfo, function BotFunction) {
 	if childCommands[parentID] == nil {
 		childCommands[parentID] = make(map[string]Command)
 	}
+	// Keep the first child registered with a trigger, the same as aliases
+	if _, ok := childCommands[parentID][command.Info.Trigger]; ok {
+		Log.Errorf("Child command %s was already registered for parent %s", command.Info.Trigger, parentID)
+		return
+	}
 	// Add the command to the map; command triggers are case-insensitive
 	childCommands[parentID][command.Info.Trigger] = command
+	addCommandSemaphore(command.Info)
 }
 
 // AddSlashCommand
 // Adds a slash command to the bot
 // Allows for separation between normal commands and slash commands.
 func AddSlashCommand(info *CommandInfo) {
-	if !info.IsParent || !info.IsChild {
+	if info.MessageOnly {
+		Log.Warningf("Command %s is message only, so it was not added as a slash command", info.Trigger)
+		return
+	}
+	if !info.IsParent && !info.IsChild {
 		s := createApplicationCommandStruct(info)
+		// Reported now, where it is easy to trace back, as well as when the command is left out of registration
+		if err := validateApplicationCommand(s); err != nil {
+			Log.Errorf("Slash command %s is invalid, so it won't be registered: %s", info.Trigger, err)
+		}
 		slashCommands[strings.ToLower(info.Trigger)] = *s
 		return
 	}
 	if info.IsParent {
-		s := createChatInputSubCmdStruct(info, childCommands[info.Trigger])
+		s := createChatInputSubCmdStruct(info, childCommands[strings.ToLower(info.Trigger)])
 		slashCommands[strings.ToLower(info.Trigger)] = *s
 		return
 	}
@@ -151,9 +407,11 @@ func AddSlashCommand(info *CommandInfo) {
 // RegisterSlashCommands
 // Registers the slash commands. Called on the ready event
 // defaults to registering commands globally, but it is dependent on the environment.
-func RegisterSlashCommands() {
+// Guilds that fail are skipped, and reported together in a *SlashRegistrationError.
+func RegisterSlashCommands() error {
+	failures := &SlashRegistrationError{Guilds: make(map[string]error)}
 	// Grab our currently registered application commands
-	currentCommands, err := Session.ApplicationCommands(Session.State.User.ID, "")
+	currentCommands, err := API.ApplicationCommands(Session.State.User.ID, "")
 	if err != nil {
 		Log.Errorf("unable to get current application commands")
 		Log.Error(err.Error())
@@ -167,20 +425,30 @@ func RegisterSlashCommands() {
 			return item.Type != discordgo.ChatApplicationCommand
 		})
 		// add all slash commands to the existing commands slice
-		for _, cmd := range slashCommands {
-			setCmd := cmd
-			commands = append(commands, &setCmd)
+		globalCommands, guildCommands, invalid := splitSlashCommands()
+		for _, err := range invalid {
+			Log.Errorf("Not registering invalid slash command: %s", err)
+		}
+		commands = append(commands, globalCommands...)
+		if ownerGuildID == "" {
+			for name := range guildCommands {
+				if isOwnerGuildOnly(name) {
+					Log.Warningf("Not registering owner guild slash command %s, since no owner guild is set", name)
+				}
+			}
 		}
 		// if the environment is dev, this is running on the dev bot, which is only in a select few guilds
 		// so lets just register commands in all guilds in the state
 		if IsDevEnv() {
 			Log.Infof("Setting slash commands in %d guilds", len(Session.State.Guilds))
 			for _, guild := range Session.State.Guilds {
-				updateCommands, err := Session.ApplicationCommandBulkOverwrite(Session.State.User.ID, guild.ID, commands)
+				guildSet := append(append([]*discordgo.ApplicationCommand{}, commands...), commandsForGuild(guild.ID, guildCommands)...)
+				updateCommands, err := API.ApplicationCommandBulkOverwrite(Session.State.User.ID, guild.ID, guildSet)
 				if err != nil {
 					Log.Errorf("unable to bulk overwrite commands in guild %s (%s)", guild.Name, guild.ID)
 					Log.Error(err.Error())
-					return
+					failures.Guilds[guild.ID] = err
+					continue
 				}
 				if updateCommands != nil && len(updateCommands) >= 0 {
 					Log.Infof("successfully bulk overwrote %d slash commands in %s (%s)", len(updateCommands), guild.Name, guild.ID)
@@ -188,14 +456,197 @@ func RegisterSlashCommands() {
 			}
 		} else {
 			// bulk register all application commands
-			_, err = Session.ApplicationCommandBulkOverwrite(Session.State.User.ID, "", commands)
+			_, err = API.ApplicationCommandBulkOverwrite(Session.State.User.ID, "", commands)
 			if err != nil {
 				Log.Error("Unable to register slash commands")
 				Log.Error(err.Error())
+				failures.Global = err
+			}
+			// guild commands are registered per guild, so they never show up anywhere else
+			if len(guildCommands) > 0 {
+				for _, guild := range Session.State.Guilds {
+					_, err = API.ApplicationCommandBulkOverwrite(Session.State.User.ID, guild.ID, commandsForGuild(guild.ID, guildCommands))
+					if err != nil {
+						Log.Errorf("Unable to register guild slash commands in %s (%s)", guild.Name, guild.ID)
+						Log.Error(err.Error())
+						failures.Guilds[guild.ID] = err
+					}
+				}
 			}
 		}
 	}
-	return
+	if failures.Global != nil || len(failures.Guilds) > 0 {
+		return failures
+	}
+	return nil
+}
+
+// splitSlashCommands
+// Returns the slash commands to register globally, and the ones only registered in some guilds, keyed by trigger:
+// owner guild commands in the owner guild, feature commands where their feature is enabled, commands
+// with GuildIDs in those guilds, and experimental commands in guilds with beta testers
+// Invalid commands are left out and returned as errors, since one invalid command fails the whole bulk overwrite.
+func splitSlashCommands() ([]*discordgo.ApplicationCommand, map[string]*discordgo.ApplicationCommand, []error) {
+	var global []*discordgo.ApplicationCommand
+	var invalid []error
+	guildCommands := make(map[string]*discordgo.ApplicationCommand)
+	for name, cmd := range buildSlashCommands() {
+		setCmd := cmd
+		if err := validateApplicationCommand(&setCmd); err != nil {
+			invalid = append(invalid, err)
+			continue
+		}
+		if isOwnerGuildOnly(name) || commandFeature(name) != "" || len(commandGuildIDs(name)) > 0 || isExperimentalTrigger(name) {
+			guildCommands[name] = &setCmd
+			continue
+		}
+		global = append(global, &setCmd)
+	}
+	return global, guildCommands, invalid
+}
+
+// SlashRegistrationError
+// Returned by RegisterSlashCommands when registering failed globally, or in some guilds.
+// A failure in one guild doesn't stop the others from being registered.
+type SlashRegistrationError struct {
+	Global error            // The error registering global commands, if there was one
+	Guilds map[string]error // The errors registering commands in each guild that failed, keyed by guild ID
+}
+
+func (e *SlashRegistrationError) Error() string {
+	var parts []string
+	if e.Global != nil {
+		parts = append(parts, "globally: "+e.Global.Error())
+	}
+	guildIDs := make([]string, 0, len(e.Guilds))
+	for id := range e.Guilds {
+		guildIDs = append(guildIDs, id)
+	}
+	sort.Strings(guildIDs)
+	for _, id := range guildIDs {
+		parts = append(parts, "in guild "+id+": "+e.Guilds[id].Error())
+	}
+	return "unable to register slash commands " + strings.Join(parts, "; ")
+}
+
+// commandsForGuild
+// Returns the guild commands that should be registered in the given guild.
+func commandsForGuild(guildID string, guildCommands map[string]*discordgo.ApplicationCommand) []*discordgo.ApplicationCommand {
+	cmds := make([]*discordgo.ApplicationCommand, 0, len(guildCommands))
+	for name, cmd := range guildCommands {
+		if isOwnerGuildOnly(name) && !IsOwnerGuild(guildID) {
+			continue
+		}
+		if feature := commandFeature(name); feature != "" && !IsFeatureEnabled(guildID, feature) {
+			continue
+		}
+		if !availableIn(commands[strings.ToLower(name)].Info, guildID) {
+			continue
+		}
+		if isExperimentalTrigger(name) && !hasBetaTesters(guildID) {
+			continue
+		}
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
+
+// commandFeature
+// Returns the feature the command with the given trigger needs, if any.
+func commandFeature(trigger string) string {
+	return commands[strings.ToLower(trigger)].Info.Feature
+}
+
+// commandGuildIDs
+// Returns the guilds the command with the given trigger is limited to, if any.
+func commandGuildIDs(trigger string) []string {
+	return commands[strings.ToLower(trigger)].Info.GuildIDs
+}
+
+// availableIn
+// Check if a command exists in a guild, which it always does unless it is limited to other guilds with GuildIDs.
+func availableIn(info CommandInfo, guildID string) bool {
+	return len(info.GuildIDs) == 0 || internal.Contains(info.GuildIDs, guildID)
+}
+
+// isOwnerGuildOnly
+// Check if the command with the given trigger is restricted to the owner guild.
+func isOwnerGuildOnly(trigger string) bool {
+	return commands[strings.ToLower(trigger)].Info.OwnerGuildOnly
+}
+
+// AddSlashGroup
+// Groups existing commands under a single top level slash command, so they are shown as /name trigger
+// The commands don't need to be slash commands themselves, and their message triggers are unaffected.
+// Grouped commands are no longer registered as top level slash commands.
+func AddSlashGroup(name string, description string, triggers ...string) {
+	name = strings.ToLower(name)
+	if _, ok := commands[name]; ok {
+		Log.Errorf("Slash group %s has the same name as a command, it will not be registered", name)
+		return
+	}
+	group := slashGroups[name]
+	group.Description = description
+	for _, trigger := range triggers {
+		group.Triggers = append(group.Triggers, strings.ToLower(trigger))
+	}
+	slashGroups[name] = group
+}
+
+// inSlashGroup
+// Check if a command trigger is part of the given slash group.
+func inSlashGroup(name string, trigger string) bool {
+	for _, t := range slashGroups[name].Triggers {
+		if t == trigger {
+			return true
+		}
+	}
+	return false
+}
+
+// buildSlashCommands
+// Returns every slash command to register, with grouped commands moved under their slash groups
+// Groups and parent commands are built here rather than when they are added, so commands can be added in any order,
+// and so choices from a ChoicesFunc are evaluated at registration time.
+func buildSlashCommands() map[string]discordgo.ApplicationCommand {
+	built := make(map[string]discordgo.ApplicationCommand, len(slashCommands)+len(slashGroups))
+	grouped := make(map[string]bool)
+	for name, group := range slashGroups {
+		children := make(map[string]Command, len(group.Triggers))
+		for _, trigger := range group.Triggers {
+			command, ok := commands[trigger]
+			if !ok {
+				Log.Errorf("Slash group %s includes %s, which is not a command", name, trigger)
+				continue
+			}
+			if command.Info.MessageOnly {
+				continue
+			}
+			children[trigger] = command
+			grouped[trigger] = true
+		}
+		built[name] = *createChatInputSubCmdStruct(&CommandInfo{Trigger: name, Description: group.Description}, children)
+	}
+	for name, cmd := range slashCommands {
+		if grouped[name] {
+			continue
+		}
+		// Parents are rebuilt so they hold every child, and commands with a ChoicesFunc so their choices are current
+		if command, ok := commands[name]; ok && command.Info.IsParent {
+			cmd = *createChatInputSubCmdStruct(&command.Info, childCommands[name])
+		} else if ok && hasChoicesFunc(&command.Info) {
+			cmd = *createApplicationCommandStruct(&command.Info)
+		}
+		built[name] = cmd
+	}
+	return built
+}
+
+// SetUnknownCommandHandler
+// Sets a function to run when a message's trigger doesn't match any command, replacing the default
+// "Command not found" reply for admins. The attempted trigger is passed to the handler as ctx.Cmd.Trigger.
+func SetUnknownCommandHandler(handler BotFunction) {
+	unknownCommandHandler = handler
 }
 
 // GetCommands
@@ -208,6 +659,101 @@ func GetCommands() map[string]CommandInfo {
 	return list
 }
 
+// GetChildCommands
+// Provide a way to read a parent command's child commands without making it possible to modify them.
+// The map is keyed by the child command triggers, and is empty if the parent has no children. Each info is a deep copy.
+func GetChildCommands(parentID string) map[string]CommandInfo {
+	list := make(map[string]CommandInfo)
+	for x, y := range childCommands[strings.ToLower(parentID)] {
+		list[x] = copyCommandInfo(y.Info)
+	}
+	return list
+}
+
+// CommandDescription
+// Everything known about a command, see DescribeCommand.
+type CommandDescription struct {
+	Info     CommandInfo          // The command's info
+	Aliases  []string             // Every alias that leads to the command, sorted
+	Children []CommandDescription // The command's child commands, sorted by trigger
+	Slash    bool                 // If the command is registered as a slash command, on its own, in a group, or as a child
+}
+
+// DescribeCommand
+// Describes a command and its children, for introspecting the command tree. The description is a deep copy,
+// so changing it has no effect on the command.
+func DescribeCommand(trigger string) (*CommandDescription, error) {
+	trigger = strings.ToLower(trigger)
+	command, ok := commands[trigger]
+	if !ok {
+		if command, ok = commands[commandAliases[trigger]]; !ok {
+			return nil, fmt.Errorf("command %s not found", trigger)
+		}
+	}
+	key := strings.ToLower(command.Info.Trigger)
+	_, slash := slashCommands[key]
+	for _, group := range slashGroups {
+		slash = slash || internal.Contains(group.Triggers, key)
+	}
+	description := describeCommand(command, slash)
+	return &description, nil
+}
+
+// describeCommand
+// Builds the description of a command, and of its children.
+func describeCommand(command Command, slash bool) CommandDescription {
+	description := CommandDescription{
+		Info:  copyCommandInfo(command.Info),
+		Slash: slash && !command.Info.MessageOnly,
+	}
+	key := strings.ToLower(command.Info.Trigger)
+	if !command.Info.IsChild {
+		for alias, trigger := range commandAliases {
+			if trigger == key && alias != key {
+				description.Aliases = append(description.Aliases, alias)
+			}
+		}
+		sort.Strings(description.Aliases)
+	}
+	for _, child := range sortedChildren(childCommands[commandKey(command.Info)]) {
+		description.Children = append(description.Children, describeCommand(child, description.Slash))
+	}
+	return description
+}
+
+// copyCommandInfo
+// Returns a deep copy of a command's info. Functions and regexes are shared, since they can't be changed.
+func copyCommandInfo(info CommandInfo) CommandInfo {
+	info.Aliases = append([]string(nil), info.Aliases...)
+	info.GuildIDs = append([]string(nil), info.GuildIDs...)
+	if info.Arguments == nil {
+		return info
+	}
+	arguments := orderedmap.New()
+	for _, k := range info.Arguments.Keys() {
+		v, _ := info.Arguments.Get(k)
+		arg := *v.(*ArgInfo)
+		arg.Choices = append([]string(nil), arg.Choices...)
+		arg.Aliases = append([]string(nil), arg.Aliases...)
+		arg.AllowedHosts = append([]string(nil), arg.AllowedHosts...)
+		arg.ChannelTypes = append([]discordgo.ChannelType(nil), arg.ChannelTypes...)
+		arg.ChoiceList = nil
+		for _, choice := range v.(*ArgInfo).ChoiceList {
+			if choice.NameLocalizations != nil {
+				localizations := make(map[discordgo.Locale]string, len(choice.NameLocalizations))
+				for locale, name := range choice.NameLocalizations {
+					localizations[locale] = name
+				}
+				choice.NameLocalizations = localizations
+			}
+			arg.ChoiceList = append(arg.ChoiceList, choice)
+		}
+		arguments.Set(k, &arg)
+	}
+	info.Arguments = arguments
+	return info
+}
+
 // customCommandHandler
 // Given a custom command, interpret and run it.
 func customCommandHandler(command CustomCommand, args []string, message *discordgo.Message) {
@@ -218,11 +764,9 @@ func customCommandHandler(command CustomCommand, args []string, message *discord
 // This handler will be added to a *discordgo.Session, and will scan an incoming messages for commands to run.
 func commandHandler(session *discordgo.Session, message *discordgo.MessageCreate) {
 	// Try getting an object for the current channel, with a fallback in case session.state is not ready or is nil
-	channel, err := session.State.Channel(message.ChannelID)
+	channel, err := lookupChannel(message.ChannelID)
 	if err != nil {
-		if channel, err = session.Channel(message.ChannelID); err != nil {
-			return
-		}
+		return
 	}
 
 	// Ignore messages sent by the bot
@@ -232,8 +776,27 @@ func commandHandler(session *discordgo.Session, message *discordgo.MessageCreate
 
 	g := GetGuild(message.GuildID)
 
-	trigger, argString := ExtractCommand(&g.Info, message.Content)
+	// Nothing is said in a muted channel, except to bot admins
+	if mutedFor(g, message.ChannelID, message.Author.ID) {
+		return
+	}
+
+	// A mention on its own gets a pointer to the prefix, so the bot can be found without knowing it
+	if isBareMention(message.Content) {
+		if !message.Author.Bot && g.MentionHelpEnabled() {
+			prefix, _ := g.Prefixes()
+			_, err = ReplyToMessage(message.Message, &discordgo.MessageSend{Content: Translate(g, MsgMentionHelp, prefix, prefix)})
+			if err != nil {
+				Log.Errorf("unable to reply to mention: %s", err)
+			}
+		}
+		return
+	}
+
+	prefix, prefixChars := g.Prefixes()
+	trigger, argString := ExtractCommand(prefix, prefixChars, message.Content)
 	if trigger == nil {
+		runPatternCommand(g, message.Message, channel)
 		return
 	}
 	//isCustom := false
@@ -271,95 +834,325 @@ func commandHandler(session *discordgo.Session, message *discordgo.MessageCreate
 	//Get the command to run
 	// Error Checking
 	command, ok := commands[commandAliases[*trigger]]
+	if ok && command.Function == nil {
+		// The alias points at a command that is only partially registered
+		Log.Errorf("Trigger %s maps to command %s, which has no function", *trigger, commandAliases[*trigger])
+		return
+	}
+	// Ignore other bots, unless the command opts in, so bots can't trigger each other in a loop
+	if message.Author.Bot && (!ok || !command.Info.AllowBots) {
+		return
+	}
 	if !ok {
-		Log.Errorf("Command was not found")
+		// Custom commands aren't core commands, but they aren't unknown either
+		if custom, isCustom := g.GetCustomCommand(*trigger); isCustom {
+			customCommandHandler(custom, strings.Fields(*argString), message.Message)
+			return
+		}
+		if runPatternCommand(g, message.Message, channel) {
+			return
+		}
+		// Let the unknown command handler take over, if one is set
+		if unknownCommandHandler != nil {
+			ctx := &CmdContext{
+				Guild:   g,
+				Cmd:     CommandInfo{Trigger: *trigger},
+				Args:    Arguments{},
+				Message: message.Message,
+				channel: channel,
+			}
+			// The handler counts as a command, so maintenance mode stops it too
+			if inMaintenanceFor(message.Author.ID) {
+				sendMaintenanceNotice(ctx)
+				return
+			}
+			defer handleCommandError(*trigger, g.ID, channel.ID, message.Author.ID)
+			unknownCommandHandler(ctx)
+			return
+		}
+		if debounceNotFound(message.Author.ID) {
+			return
+		}
+		Log.Errorf("Command %q was not found", *trigger)
 		if IsAdmin(message.Author.ID) {
-			Session.MessageReactionAdd(message.ChannelID, message.ID, "<:redtick:861413502991073281>")
-			Session.ChannelMessageSendReply(message.ChannelID, "<:redtick:861413502991073281> Error! Command not found!", message.MessageReference)
+			API.MessageReactionAdd(message.ChannelID, message.ID, "<:redtick:861413502991073281>")
+			_, _ = ReplyToMessage(message.Message, &discordgo.MessageSend{Content: "<:redtick:861413502991073281> " + Translate(g, MsgCommandNotFound)})
 		}
 		return
 	}
+	if command.Info.SlashOnly {
+		return
+	}
+	// Owner guild commands, disabled features and guild-limited commands don't exist anywhere else
+	if command.Info.OwnerGuildOnly && !IsOwnerGuild(message.GuildID) {
+		return
+	}
+	if !availableIn(command.Info, message.GuildID) {
+		return
+	}
+	if command.Info.Feature != "" && !IsFeatureEnabled(message.GuildID, command.Info.Feature) {
+		return
+	}
 	// Check if the command is public, or if the current user is a bot moderator
 	// Bot admins supercede both checks
 	//if IsAdmin(message.Author.ID) || command.Info.Public || g.IsMod(message.Author.ID) {
 	// Run the command with the necessary context
 	if command.Info.IsTyping && g.Info.ResponseChannelID == "" {
-		_ = Session.ChannelTyping(message.ChannelID)
+		// Deferred, so typing stops even if the command panics
+		stopTyping := startTyping(message.ChannelID)
+		defer stopTyping()
 	}
 	// The command is valid, so now we need to delete the invoking message if that is configured
 	//if g.Info.DeletePolicy {
-	//	err := Session.ChannelMessageDelete(message.ChannelID, message.ID)
+	//	err := API.ChannelMessageDelete(message.ChannelID, message.ID)
 	//	if err != nil {
 	//		SendErrorReport(message.GuildID, message.ChannelID, message.Author.ID, "Failed to delete message: "+message.ID, err)
 	//	}
 	//}
 
-	defer handleCommandError(g.ID, channel.ID, message.Author.ID)
+	defer handleCommandError(command.Info.Trigger, g.ID, channel.ID, message.Author.ID)
 	if command.Info.IsParent {
-		handleChildCommand(*argString, command, message.Message, g)
+		handleChildCommand(*argString, command, message.Message, g, channel)
 		return
 	}
-	command.Function(&CmdContext{
-		Guild:   g,
-		Cmd:     command.Info,
-		Args:    *ParseArguments(*argString, command.Info.Arguments),
-		Message: message.Message,
+	runCommand(command, &CmdContext{
+		Guild:     g,
+		Cmd:       command.Info,
+		Args:      *ParseArguments(*argString, command.Info.Arguments),
+		Message:   message.Message,
+		ArgString: *argString,
+		channel:   channel,
 	})
-	// Makes sure that variables ran in ParseArguments are gone.
-	if commandsGC == 25 && commandsGC > 25 {
-		debug.FreeOSMemory()
-		commandsGC = 0
-	} else {
-		commandsGC++
-	}
 	return
 	//}
 	//}
 }
 
 // -- Helper Methods.
-func handleChildCommand(argString string, command Command, message *discordgo.Message, guild *Guild) {
+
+// handleChildCommand
+// Runs the child of a parent command named by the first word of argString, or the parent itself if no child matches.
+// Either way the context carries what the user typed, so parents can fall back to their own handling.
+func handleChildCommand(argString string, command Command, message *discordgo.Message, guild *Guild, channel *discordgo.Channel) {
 	split := strings.SplitN(argString, " ", 2)
 
 	childCmd, ok := childCommands[command.Info.Trigger][split[0]]
-	if !ok {
-		command.Function(&CmdContext{
-			Guild:   guild,
-			Cmd:     command.Info,
-			Args:    nil,
-			Message: message,
+	// Slash only children can't be run from a message, so the parent gets to handle it
+	if !ok || childCmd.Info.SlashOnly {
+		runCommand(command, &CmdContext{
+			Guild:     guild,
+			Cmd:       command.Info,
+			Args:      nil,
+			Message:   message,
+			ArgString: argString,
+			channel:   channel,
 		})
 		return
 	}
-	if len(split) < 2 {
-		childCmd.Function(&CmdContext{
-			Guild:   guild,
-			Cmd:     childCmd.Info,
-			Args:    *ParseArguments("", childCmd.Info.Arguments),
-			Message: message,
-		})
-		return
+	childArgs := ""
+	if len(split) > 1 {
+		childArgs = split[1]
 	}
-	childCmd.Function(&CmdContext{
-		Guild:   guild,
-		Cmd:     childCmd.Info,
-		Args:    *ParseArguments(split[1], childCmd.Info.Arguments),
-		Message: message,
+	runCommand(childCmd, &CmdContext{
+		Guild:      guild,
+		Cmd:        childCmd.Info,
+		Args:       *ParseArguments(childArgs, childCmd.Info.Arguments),
+		Message:    message,
+		ArgString:  childArgs,
+		Subcommand: childCmd.Info.Trigger,
+		channel:    channel,
 	})
-	return
 }
 
-func handleCommandError(gID string, cId string, uId string) {
+// typingInterval
+// How often the typing indicator is sent while a command runs. Discord shows it for about ten seconds.
+const typingInterval = 8 * time.Second
+
+// startTyping
+// Shows the typing indicator in a channel until the returned function is called.
+func startTyping(channelID string) func() {
+	done := make(chan struct{})
+	_ = API.ChannelTyping(channelID)
+	go func() {
+		ticker := time.NewTicker(typingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_ = API.ChannelTyping(channelID)
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// runCommand
+// Runs a command's function with the given context, enforcing arg length limits and the command's concurrency limit.
+func runCommand(command Command, ctx *CmdContext) {
+	// A command without a function means the command maps are out of sync, so there is nothing sensible to run
+	if command.Function == nil {
+		Log.Errorf("Command %s has no function to run, ignoring it", ctx.Cmd.Trigger)
+		return
+	}
+	// Maintenance mode and the kill switch come before anything else the command might do
+	if inMaintenanceFor(ctx.AuthorID()) {
+		sendMaintenanceNotice(ctx)
+		return
+	}
+	if isDisabledFor(command.Info, ctx.AuthorID()) {
+		sendBlockedNotice(ctx, command.Info)
+		return
+	}
+	// Experimental commands don't exist for anyone outside the beta, except that slash commands need an answer
+	if isHiddenFor(command.Info, ctx.Guild, ctx.AuthorID()) {
+		if ctx.Interaction != nil {
+			sendNotice(ctx, ctx.Translate(MsgNoPermission))
+		}
+		return
+	}
+	// Parents run without a subcommand have no args, but the steps below can fill some in
+	if ctx.Args == nil {
+		ctx.Args = Arguments{}
+	}
+	collapseRepeatedArgs(ctx.Args, command.Info.Arguments)
+	if err := resolveReplyArgs(ctx); err != nil {
+		sendNotice(ctx, ctx.Translate(MsgInvalidArguments, err))
+		return
+	}
+	resolveInvokerArgs(ctx)
+	if err := resolveFuzzyMembers(ctx); err != nil {
+		sendNotice(ctx, ctx.Translate(MsgInvalidArguments, err))
+		return
+	}
+	if err := checkArgLengths(ctx.Args, command.Info.Arguments); err != nil {
+		sendNotice(ctx, ctx.Translate(MsgInvalidArguments, err))
+		return
+	}
+	if err := checkChannelTypes(ctx.Args, command.Info.Arguments); err != nil {
+		sendNotice(ctx, ctx.Translate(MsgInvalidArguments, err))
+		return
+	}
+	if err := checkArgValues(ctx.Args, command.Info.Arguments); err != nil {
+		sendNotice(ctx, ctx.Translate(MsgInvalidArguments, err))
+		return
+	}
+	if err := applyTransforms(ctx.Args, command.Info.Arguments); err != nil {
+		sendNotice(ctx, ctx.Translate(MsgInvalidArguments, err))
+		return
+	}
+	release, ok := acquireCommand(command.Info)
+	if !ok {
+		sendNotice(ctx, ctx.Translate(MsgCommandBusy))
+		return
+	}
+	defer release()
+	// The cooldown only starts once the command is sure to run, so a busy command doesn't use it up
+	if remaining, ok := startCooldown(command.Info, cooldownOwner(command.Info.CooldownScope, ctx)); !ok {
+		sendNotice(ctx, ctx.Translate(MsgCooldown, FormatDuration(remaining)))
+		return
+	}
+	if command.Info.Serialized {
+		unlock := lockSerialized(command.Info, ctx.Guild)
+		defer unlock()
+	}
+	observeCommand(command, ctx)
+}
+
+// panicError
+// Turns a recovered panic value into an error, since panics aren't always runtime errors (e.g: panic("oops")).
+func panicError(r interface{}) error {
+	if err, ok := r.(error); ok {
+		return err
+	}
+	return fmt.Errorf("%v", r)
+}
+
+// recoverReportFailure
+// Deferred while handling a panic, so a second panic while reporting the first is logged and dropped.
+func recoverReportFailure(trigger string) {
+	if r := recover(); r != nil {
+		Log.Errorf("Panicked while reporting an error in %s, giving up on the report: %v\n%s", trigger, r, debug.Stack())
+	}
+}
+
+// lockSerialized
+// Waits until no other invocation of the command is running in the guild, returning a function to let the next one run.
+// Only the same command in the same guild waits, so everything else keeps running concurrently.
+func lockSerialized(info CommandInfo, guild *Guild) func() {
+	key := commandKey(info)
+	if guild != nil && guild.Guild != nil {
+		key = guild.ID + " " + key
+	}
+	serialLocksLock.Lock()
+	lock, ok := serialLocks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		serialLocks[key] = lock
+	}
+	serialLocksLock.Unlock()
+	lock.Lock()
+	return lock.Unlock
+}
+
+// commandKey
+// Returns a key that is unique to a command, since child commands can share triggers with other commands.
+func commandKey(info CommandInfo) string {
+	if info.IsChild {
+		return strings.ToLower(info.ParentID) + " " + strings.ToLower(info.Trigger)
+	}
+	return strings.ToLower(info.Trigger)
+}
+
+// addCommandSemaphore
+// Creates the semaphore for a command, if it has a concurrency limit.
+func addCommandSemaphore(info CommandInfo) {
+	if info.MaxConcurrent <= 0 {
+		return
+	}
+	commandSemaphores[commandKey(info)] = make(chan struct{}, info.MaxConcurrent)
+}
+
+// acquireCommand
+// Reserves a slot to run a command, returning a function to release it
+// Returns false if the command is already running as many times as it is allowed to.
+func acquireCommand(info CommandInfo) (func(), bool) {
+	semaphore, ok := commandSemaphores[commandKey(info)]
+	if !ok {
+		return func() {}, true
+	}
+	select {
+	case semaphore <- struct{}{}:
+		return func() { <-semaphore }, true
+	default:
+		return nil, false
+	}
+}
+
+// handleCommandError
+// Recovers from a panic in a message command, reports it to the admins, and briefly tells the user something went wrong.
+// Reporting is done defensively, so a failure while reporting can't take the bot down with it.
+func handleCommandError(trigger string, gID string, cId string, uId string) {
 	if r := recover(); r != nil {
-		Log.Warningf("Recovering from panic: %s", r)
+		defer recoverReportFailure(trigger)
+		Log.Warningf("Recovering from panic: %v\n%s", r, debug.Stack())
 		Log.Warningf("Sending Error report to admins")
-		SendErrorReport(gID, cId, uId, "Error!", r.(runtime.Error))
-		message, err := Session.ChannelMessageSend(cId, "Error!")
+		sendErrorReport(trigger, gID, cId, uId, "Error!", panicError(r))
+		var message *discordgo.Message
+		err := sendWithRetry(func() (err error) {
+			message, err = API.ChannelMessageSend(cId, Translate(GetGuild(gID), MsgCommandError))
+			return err
+		})
 		if err != nil {
 			Log.Errorf("err sending message %s", err)
+			return
 		}
 		time.Sleep(5 * time.Second)
-		_ = Session.ChannelMessageDelete(cId, message.ID)
+		_ = API.ChannelMessageDelete(cId, message.ID)
 		return
 	}
 	return
@@ -0,0 +1,533 @@
+package core
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestCommandHandlerDanglingAlias(t *testing.T) {
+	mock := useMockSession(t)
+
+	// "ghost" points at a trigger that was never registered, and "hollow" at a command without a function
+	commandAliases["ghost"] = "missing"
+	commandAliases["hollow"] = "hollow"
+	commands["hollow"] = Command{Info: CommandInfo{Trigger: "hollow"}}
+	t.Cleanup(func() {
+		delete(commandAliases, "ghost")
+		delete(commandAliases, "hollow")
+		delete(commands, "hollow")
+	})
+
+	for _, content := range []string{"!ghost", "!hollow some args"} {
+		commandHandler(Session, &discordgo.MessageCreate{Message: &discordgo.Message{
+			ID:        "1",
+			ChannelID: "2",
+			Content:   content,
+			Author:    &discordgo.User{ID: "3"},
+		}})
+	}
+
+	// A panic would have been recovered by handleCommandError, which reports it in the channel
+	if len(mock.sent) != 0 {
+		t.Errorf("expected nothing to be sent, got %+v", mock.sent)
+	}
+}
+
+func TestAddChildCommandDuplicate(t *testing.T) {
+	t.Cleanup(func() { delete(childCommands, "dupeparent") })
+
+	ran := ""
+	first := CreateCommandInfo("child", "The first child", true, Utility)
+	first.SetParent(false, "dupeparent")
+	AddChildCommand(first, func(ctx *CmdContext) { ran = "first" })
+	second := CreateCommandInfo("child", "A copy-pasted child", true, Utility)
+	second.SetParent(false, "dupeparent")
+	AddChildCommand(second, func(ctx *CmdContext) { ran = "second" })
+
+	if len(childCommands["dupeparent"]) != 1 {
+		t.Fatalf("expected a single child, got %d", len(childCommands["dupeparent"]))
+	}
+	child := childCommands["dupeparent"]["child"]
+	if child.Info.Description != "The first child" {
+		t.Errorf("expected the first child to be kept, got %q", child.Info.Description)
+	}
+	child.Function(&CmdContext{})
+	if ran != "first" {
+		t.Errorf("expected the first child's function to be kept, got %q", ran)
+	}
+}
+
+func TestGetChildCommandsCopy(t *testing.T) {
+	t.Cleanup(func() { delete(childCommands, "copyparent") })
+
+	info := CreateCommandInfo("child", "A child", true, Utility).
+		AddArg("name", String, ArgOption, "A name", false, "")
+	info.AddCmdAlias([]string{"kid"})
+	info.SetParent(false, "copyparent")
+	AddChildCommand(info, func(ctx *CmdContext) {})
+
+	copied := GetChildCommands("copyparent")["child"]
+	copied.Aliases[0] = "changed"
+	arg, _ := copied.Arguments.Get("name")
+	arg.(*ArgInfo).Description = "changed"
+	copied.Arguments.Delete("name")
+
+	live := childCommands["copyparent"]["child"].Info
+	if live.Aliases[0] != "kid" {
+		t.Errorf("expected the registered aliases to be unchanged, got %v", live.Aliases)
+	}
+	liveArg, ok := live.Arguments.Get("name")
+	if !ok || liveArg.(*ArgInfo).Description != "A name" {
+		t.Errorf("expected the registered args to be unchanged")
+	}
+}
+
+func TestRemapTrigger(t *testing.T) {
+	oldCommands, oldAliases, oldSlash, oldProvider := commands, commandAliases, slashCommands, currentProvider
+	commands, commandAliases, slashCommands = make(map[string]Command), make(map[string]string), make(map[string]discordgo.ApplicationCommand)
+	var saved []TriggerRemap
+	currentProvider = GuildProvider{
+		SaveRemaps: func(remaps []TriggerRemap) { saved = remaps },
+		LoadRemaps: func() []TriggerRemap { return saved },
+	}
+	t.Cleanup(func() {
+		commands, commandAliases, slashCommands, currentProvider = oldCommands, oldAliases, oldSlash, oldProvider
+		triggerRemaps = nil
+	})
+
+	info := CreateCommandInfo("oldname", "A command being renamed", true, Utility)
+	info.AddCmdAlias([]string{"on"})
+	AddCommand(info, func(ctx *CmdContext) {})
+	AddSlashCommand(info)
+	AddCommand(CreateCommandInfo("taken", "Another command", true, Utility), func(ctx *CmdContext) {})
+
+	if err := RemapTrigger("oldname", "taken"); err == nil {
+		t.Errorf("expected remapping onto another command's trigger to fail")
+	}
+	if err := RemapTrigger("oldname", "newname"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := commands["oldname"]; !ok {
+		t.Fatalf("expected the remap to wait for a restart")
+	}
+	if err := RemapTrigger("oldname", "other"); err == nil {
+		t.Errorf("expected remapping a command with a saved remap to fail")
+	}
+	// The saved remap is applied when the bot starts again
+	loadTriggerRemaps()
+	if _, ok := commands["oldname"]; ok {
+		t.Errorf("expected the command to be moved off its old trigger")
+	}
+	if commands["newname"].Info.Trigger != "newname" {
+		t.Errorf("expected the command to be under its new trigger, got %+v", commands["newname"].Info)
+	}
+	for _, alias := range []string{"oldname", "on", "newname"} {
+		if commandAliases[alias] != "newname" {
+			t.Errorf("expected alias %s to lead to newname, got %q", alias, commandAliases[alias])
+		}
+	}
+	if _, ok := slashCommands["newname"]; !ok || len(slashCommands) != 1 {
+		t.Errorf("expected the slash command to be renamed, got %v", slashCommands)
+	}
+	if len(saved) != 1 || saved[0] != (TriggerRemap{Old: "oldname", New: "newname"}) {
+		t.Errorf("expected the remap to be saved, got %+v", saved)
+	}
+}
+
+func TestExperimentalCommand(t *testing.T) {
+	useMockSession(t)
+
+	ran := false
+	command := Command{
+		Info:     CommandInfo{Trigger: "beta", Experimental: true},
+		Function: func(ctx *CmdContext) { ran = true },
+	}
+	g := &Guild{Guild: &discordgo.Guild{ID: "1"}, Info: NewGuildInfo()}
+	message := &discordgo.Message{ID: "2", ChannelID: "3", Author: &discordgo.User{ID: "4"}}
+	runCommand(command, &CmdContext{Guild: g, Cmd: command.Info, Message: message})
+	if ran {
+		t.Errorf("expected the experimental command not to run for someone outside the beta")
+	}
+	if !isHiddenFor(command.Info, g, "4") {
+		t.Errorf("expected the command to be hidden from help for someone outside the beta")
+	}
+	if HelpLabel(command.Info) != "beta (beta)" {
+		t.Errorf("expected the command to be labeled, got %q", HelpLabel(command.Info))
+	}
+
+	addAdmin("5")
+	t.Cleanup(func() { delete(botAdmins, "5") })
+	message.Author.ID = "5"
+	runCommand(command, &CmdContext{Guild: g, Cmd: command.Info, Message: message})
+	if !ran {
+		t.Errorf("expected the experimental command to run for a bot admin")
+	}
+}
+
+func TestGuildLimitedSlashCommand(t *testing.T) {
+	oldCommands, oldSlash := commands, slashCommands
+	commands, slashCommands = make(map[string]Command), make(map[string]discordgo.ApplicationCommand)
+	t.Cleanup(func() { commands, slashCommands = oldCommands, oldSlash })
+
+	partner := CreateCommandInfo("partner", "Only for partners", true, Utility).SetGuildIDs("1")
+	AddCommand(partner, func(ctx *CmdContext) {})
+	AddSlashCommand(partner)
+	everywhere := CreateCommandInfo("everywhere", "For everyone", true, Utility)
+	AddCommand(everywhere, func(ctx *CmdContext) {})
+	AddSlashCommand(everywhere)
+
+	global, guildCommands, _ := splitSlashCommands()
+	if len(global) != 1 || global[0].Name != "everywhere" {
+		t.Errorf("expected only the unlimited command to be global, got %d commands", len(global))
+	}
+	if cmds := commandsForGuild("1", guildCommands); len(cmds) != 1 || cmds[0].Name != "partner" {
+		t.Errorf("expected the limited command in its guild, got %v", cmds)
+	}
+	if cmds := commandsForGuild("2", guildCommands); len(cmds) != 0 {
+		t.Errorf("expected the limited command nowhere else, got %v", cmds)
+	}
+}
+
+func TestExperimentalSlashCommand(t *testing.T) {
+	oldCommands, oldSlash, oldGuilds := commands, slashCommands, Guilds
+	commands, slashCommands = make(map[string]Command), make(map[string]discordgo.ApplicationCommand)
+	Guilds = map[string]*Guild{
+		"1": {Guild: &discordgo.Guild{ID: "1"}, Info: GuildInfo{BetaRole: "10"}},
+		"2": {Guild: &discordgo.Guild{ID: "2"}, Info: NewGuildInfo()},
+	}
+	t.Cleanup(func() { commands, slashCommands, Guilds = oldCommands, oldSlash, oldGuilds })
+
+	beta := CreateCommandInfo("beta", "Not ready yet", true, Utility).SetExperimental(true)
+	AddCommand(beta, func(ctx *CmdContext) {})
+	AddSlashCommand(beta)
+
+	global, guildCommands, _ := splitSlashCommands()
+	if len(global) != 0 {
+		t.Errorf("expected the experimental command not to be global, got %d commands", len(global))
+	}
+	if cmds := commandsForGuild("1", guildCommands); len(cmds) != 1 || cmds[0].Name != "beta" {
+		t.Errorf("expected the experimental command in the guild with a beta role, got %v", cmds)
+	}
+	if cmds := commandsForGuild("2", guildCommands); len(cmds) != 0 {
+		t.Errorf("expected the experimental command nowhere else, got %v", cmds)
+	}
+}
+
+func TestPatternCommand(t *testing.T) {
+	useMockSession(t)
+	oldGuilds, oldProvider, oldPatterns := Guilds, currentProvider, patternCommands
+	oldObservers, oldDisabled := observers, disabledCommands
+	Guilds = nil
+	currentProvider = GuildProvider{Save: func(*Guild) {}}
+	patternCommands = nil
+	observers = nil
+	disabledCommands = make(map[string]bool)
+	t.Cleanup(func() {
+		Guilds, currentProvider, patternCommands = oldGuilds, oldProvider, oldPatterns
+		observers, disabledCommands = oldObservers, oldDisabled
+	})
+
+	AddPatternCommand(regexp.MustCompile(`(a+)+b`), func(*CmdContext) {})
+	if len(PatternCommands()) != 0 {
+		t.Fatalf("expected a nested repeat to be rejected, got %v", PatternCommands())
+	}
+	ran := 0
+	hello := regexp.MustCompile(`(?i)^hello\b`)
+	AddPatternCommand(hello, func(ctx *CmdContext) { ran++ })
+
+	send := func(guildID, content string) {
+		commandHandler(Session, &discordgo.MessageCreate{Message: &discordgo.Message{
+			ID:        "1",
+			ChannelID: "2",
+			GuildID:   guildID,
+			Content:   content,
+			Author:    &discordgo.User{ID: "3"},
+		}})
+	}
+	send("400000000000000000", "Hello there")
+	if ran != 0 {
+		t.Fatalf("expected the pattern command to be off until a guild enables it")
+	}
+	if err := GetGuild("400000000000000000").EnablePatternCommand(hello.String()); err != nil {
+		t.Fatal(err)
+	}
+	if err := GetGuild("400000000000000000").EnablePatternCommand("nope"); err == nil {
+		t.Errorf("expected an unregistered pattern to be rejected")
+	}
+	send("400000000000000000", "Hello there")
+	send("400000000000000000", "well hello")
+	send("500000000000000000", "hello again")
+	if ran != 1 {
+		t.Errorf("expected the pattern command to run once, ran %d times", ran)
+	}
+
+	var seen []string
+	AddCommandObserver(func(run CommandRun) { seen = append(seen, run.Trigger) })
+	send("400000000000000000", "hello observers")
+	if len(seen) != 1 || seen[0] != strings.ToLower(hello.String()) {
+		t.Errorf("expected observers to see the pattern run under its source, got %v", seen)
+	}
+	DisableCommandGlobally(hello.String())
+	send("400000000000000000", "hello anyone")
+	if ran != 2 {
+		t.Errorf("expected the kill switch to stop the pattern command, ran %d times", ran)
+	}
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	mock := useMockSession(t)
+	SetMaintenanceMode(true, "back soon")
+	t.Cleanup(func() { SetMaintenanceMode(false, "") })
+
+	ran := false
+	command := Command{
+		Info:     CommandInfo{Trigger: "work"},
+		Function: func(ctx *CmdContext) { ran = true },
+	}
+	g := &Guild{Guild: &discordgo.Guild{ID: "1"}, Info: NewGuildInfo()}
+	message := &discordgo.Message{ID: "2", ChannelID: "3", Author: &discordgo.User{ID: "4"}}
+	runCommand(command, &CmdContext{Guild: g, Cmd: command.Info, Message: message})
+	if ran {
+		t.Errorf("expected the command not to run during maintenance")
+	}
+	if len(mock.sent) != 1 || mock.sent[0].Content != "back soon" {
+		t.Errorf("expected the maintenance message to be sent, got %+v", mock.sent)
+	}
+
+	addAdmin("5")
+	t.Cleanup(func() { delete(botAdmins, "5") })
+	message.Author.ID = "5"
+	runCommand(command, &CmdContext{Guild: g, Cmd: command.Info, Message: message})
+	if !ran {
+		t.Errorf("expected the command to run for a bot admin during maintenance")
+	}
+}
+
+func TestTransformNonString(t *testing.T) {
+	info := CreateCommandInfo("transform", "Transforms its args", true, Utility).
+		AddArg("count", String, ArgOption, "A count", true, "").
+		AddArg("link", String, ArgOption, "A link", true, "").
+		SetTransform("count", func(in string) (interface{}, error) { return len(in), nil }).
+		SetTransform("link", func(in string) (interface{}, error) { return url.Parse(in) })
+	args := Arguments{"count": {Value: "abc"}, "link": {Value: "https://example.com"}}
+	if err := applyTransforms(args, info.Arguments); err != nil {
+		t.Fatal(err)
+	}
+	if v := args["count"].StringValue(); v != "3" {
+		t.Errorf("expected the int to be formatted, got %q", v)
+	}
+	if v := args["link"].StringValue(); v != "https://example.com" {
+		t.Errorf("expected the URL to be formatted, got %q", v)
+	}
+}
+
+func TestUnknownCommandHandlerSkipsCustomCommands(t *testing.T) {
+	useMockSession(t)
+	oldGuilds, oldProvider, oldHandler := Guilds, currentProvider, unknownCommandHandler
+	Guilds = nil
+	currentProvider = GuildProvider{Save: func(*Guild) {}}
+	var unknown []string
+	SetUnknownCommandHandler(func(ctx *CmdContext) { unknown = append(unknown, ctx.Cmd.Trigger) })
+	t.Cleanup(func() {
+		Guilds, currentProvider, unknownCommandHandler = oldGuilds, oldProvider, oldHandler
+	})
+
+	if err := GetGuild("600000000000000000").AddCustomCommand("rules", "Be nice", true); err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"!rules", "!nothing"} {
+		commandHandler(Session, &discordgo.MessageCreate{Message: &discordgo.Message{
+			ID:        "1",
+			ChannelID: "2",
+			GuildID:   "600000000000000000",
+			Content:   content,
+			Author:    &discordgo.User{ID: "3"},
+		}})
+	}
+	if len(unknown) != 1 || unknown[0] != "nothing" {
+		t.Errorf("expected only the unknown trigger to reach the handler, got %v", unknown)
+	}
+}
+
+func TestCheckColors(t *testing.T) {
+	info := CreateCommandInfo("paint", "Paints something", true, Utility).
+		AddArg("color", Color, ArgOption, "The color", true, "")
+	if err := checkArgValues(*ParseArguments("#00FF00", info.Arguments), info.Arguments); err != nil {
+		t.Errorf("expected a valid color to pass, got %s", err)
+	}
+	if err := checkArgValues(*ParseArguments("notacolor", info.Arguments), info.Arguments); err == nil {
+		t.Errorf("expected an invalid color to be rejected on the message path")
+	}
+	if err := checkArgValues(Arguments{"color": {Value: "bogus"}}, info.Arguments); err == nil {
+		t.Errorf("expected an invalid color to be rejected on the slash path")
+	}
+	if err := checkArgValues(Arguments{"color": {Value: float64(255)}}, info.Arguments); err != nil {
+		t.Errorf("expected a numeric color to pass, got %s", err)
+	}
+
+	optional := CreateCommandInfo("tint", "Tints something", true, Utility).
+		AddArg("color", Color, ArgOption, "The color", false, "")
+	for _, input := range []string{"notacolor", "--color notacolor"} {
+		if err := checkArgValues(*ParseArguments(input, optional.Arguments), optional.Arguments); err == nil {
+			t.Errorf("%q: expected an invalid optional color to be rejected", input)
+		}
+	}
+	if err := checkArgValues(*ParseArguments("", optional.Arguments), optional.Arguments); err != nil {
+		t.Errorf("expected an optional color that wasn't given to pass, got %s", err)
+	}
+}
+
+func TestCheckEmojis(t *testing.T) {
+	info := CreateCommandInfo("react", "Reacts with an emoji", true, Utility).
+		AddArg("emoji", Emoji, ArgOption, "The emoji", true, "")
+	for _, valid := range []string{"👍", "<:blob:123456789012345678>"} {
+		args := *ParseArguments(valid, info.Arguments)
+		if err := checkArgValues(args, info.Arguments); err != nil {
+			t.Errorf("expected %q to pass, got %s", valid, err)
+		}
+		if args["emoji"].EmojiValue() == "" {
+			t.Errorf("expected %q to have an emoji value", valid)
+		}
+	}
+	if err := checkArgValues(*ParseArguments("smile", info.Arguments), info.Arguments); err == nil {
+		t.Errorf("expected an invalid emoji to be rejected on the message path")
+	}
+	if err := checkArgValues(Arguments{"emoji": {Value: "smile"}}, info.Arguments); err == nil {
+		t.Errorf("expected an invalid emoji to be rejected on the slash path")
+	}
+
+	optional := CreateCommandInfo("mark", "Marks a message", true, Utility).
+		AddArg("emoji", Emoji, ArgOption, "The emoji", false, "")
+	for _, input := range []string{"smile", "--emoji smile"} {
+		if err := checkArgValues(*ParseArguments(input, optional.Arguments), optional.Arguments); err == nil {
+			t.Errorf("%q: expected an invalid optional emoji to be rejected", input)
+		}
+	}
+	if err := checkArgValues(*ParseArguments("", optional.Arguments), optional.Arguments); err != nil {
+		t.Errorf("expected an optional emoji that wasn't given to pass, got %s", err)
+	}
+}
+
+func TestCheckArgValues(t *testing.T) {
+	info := CreateCommandInfo("post", "Posts something", true, Utility).
+		AddArg("link", URL, ArgOption, "Where to post from", false, "").
+		SetAllowedHosts("link", "example.com")
+	for _, test := range []struct {
+		guard ArgTypeGuards
+		valid string
+		want  string
+	}{
+		{Schedule, "9:30", "30 9 * * *"},
+		{MessageLink, "https://discord.com/channels/111111111111111111/222222222222222222/333333333333333333", "https://discord.com/channels/111111111111111111/222222222222222222/333333333333333333"},
+		{URL, "<https://example.com/a>", "https://example.com/a"},
+	} {
+		info := CreateCommandInfo("check", "Checks an arg", true, Utility).
+			AddArg("value", test.guard, ArgOption, "The value", false, "")
+		args := *ParseArguments(test.valid, info.Arguments)
+		if err := checkArgValues(args, info.Arguments); err != nil {
+			t.Errorf("%s: expected %q to pass, got %s", test.guard, test.valid, err)
+		}
+		if got := args["value"].StringValue(); got != test.want {
+			t.Errorf("%s: expected %q to be normalized to %q, got %q", test.guard, test.valid, test.want, got)
+		}
+		for _, input := range []string{"nonsense", "--value nonsense"} {
+			if err := checkArgValues(*ParseArguments(input, info.Arguments), info.Arguments); err == nil {
+				t.Errorf("%s: expected %q to be rejected", test.guard, input)
+			}
+		}
+	}
+
+	// Slash command values skip the parser, so they are checked and normalized here
+	args := Arguments{"link": {Value: "<https://cdn.example.com/b>"}}
+	if err := checkArgValues(args, info.Arguments); err != nil || args["link"].StringValue() != "https://cdn.example.com/b" {
+		t.Errorf("expected the slash value to be normalized, got %q and %v", args["link"].StringValue(), err)
+	}
+	if err := checkArgValues(Arguments{"link": {Value: "https://other.com/"}}, info.Arguments); err == nil {
+		t.Errorf("expected a host outside the allowlist to be rejected")
+	}
+}
+
+func TestCooldownAfterBusy(t *testing.T) {
+	useMockSession(t)
+
+	ran := 0
+	command := Command{
+		Info:     CommandInfo{Trigger: "slow", MaxConcurrent: 1, Cooldown: time.Minute},
+		Function: func(ctx *CmdContext) { ran++ },
+	}
+	addCommandSemaphore(command.Info)
+	t.Cleanup(func() {
+		delete(commandSemaphores, "slow")
+		ClearAllCooldowns("4")
+	})
+	message := &discordgo.Message{ID: "2", ChannelID: "3", Author: &discordgo.User{ID: "4"}}
+
+	release, _ := acquireCommand(command.Info)
+	runCommand(command, &CmdContext{Cmd: command.Info, Message: message})
+	release()
+	runCommand(command, &CmdContext{Cmd: command.Info, Message: message})
+	if ran != 1 {
+		t.Fatalf("expected the command to run once its busy slot was free, ran %d times", ran)
+	}
+	runCommand(command, &CmdContext{Cmd: command.Info, Message: message})
+	if ran != 1 {
+		t.Errorf("expected the second run to be on cooldown")
+	}
+}
+
+func TestPruneCooldowns(t *testing.T) {
+	cooldownsLock.Lock()
+	cooldowns["expired"] = map[string]time.Time{"slow": time.Now().Add(-time.Second)}
+	lastCooldownPrune = time.Time{}
+	cooldownsLock.Unlock()
+	t.Cleanup(func() { ClearAllCooldowns("5") })
+
+	startCooldown(CommandInfo{Trigger: "slow", Cooldown: time.Minute}, "5")
+	cooldownsLock.Lock()
+	defer cooldownsLock.Unlock()
+	if _, ok := cooldowns["expired"]; ok {
+		t.Errorf("expected the expired cooldown to be pruned")
+	}
+}
+
+func TestClearScopedCooldown(t *testing.T) {
+	info := CommandInfo{Trigger: "shared", Cooldown: time.Minute, CooldownScope: CooldownChannel}
+	ctx := &CmdContext{Message: &discordgo.Message{ChannelID: "7", Author: &discordgo.User{ID: "8"}}}
+	startCooldown(info, cooldownOwner(info.CooldownScope, ctx))
+	if cleared := ClearAllCooldowns("7"); cleared != 0 {
+		t.Errorf("expected a user clear not to touch the channel cooldown, cleared %d", cleared)
+	}
+	if cleared := ClearScopedCooldown(CooldownChannel, "7", "shared"); cleared != 1 {
+		t.Errorf("expected the channel cooldown to be cleared, cleared %d", cleared)
+	}
+}
+
+func TestMaintenanceModeUnknownCommand(t *testing.T) {
+	mock := useMockSession(t)
+	oldHandler := unknownCommandHandler
+	ran := false
+	SetUnknownCommandHandler(func(ctx *CmdContext) { ran = true })
+	SetMaintenanceMode(true, "back soon")
+	t.Cleanup(func() {
+		unknownCommandHandler = oldHandler
+		SetMaintenanceMode(false, "")
+	})
+
+	commandHandler(Session, &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        "1",
+		ChannelID: "2",
+		Content:   "!whatever",
+		Author:    &discordgo.User{ID: "3"},
+	}})
+	if ran {
+		t.Errorf("expected the unknown command handler not to run during maintenance")
+	}
+	if len(mock.sent) != 1 || mock.sent[0].Content != "back soon" {
+		t.Errorf("expected the maintenance message to be sent, got %+v", mock.sent)
+	}
+}
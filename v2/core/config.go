@@ -35,6 +35,11 @@ var botAdmins = make(map[string]bool)
 // Similar to BotAdmins, this isn't saved to .json and is added programmatically.
 var botToken = ""
 
+// ownerGuildID
+// The ID of the bot owner's home guild, where OwnerGuildOnly commands can be used
+// Set with the OWNER_GUILD_ID environment variable, or SetOwnerGuild.
+var ownerGuildID = ""
+
 // ColorSuccess
 // The color to use for response embeds reporting success.
 var ColorSuccess = 0x55F485
@@ -63,6 +68,11 @@ func setupEnv() {
 			}
 		}
 	}
+
+	// Get the owner guild id
+	if guildID, _ := os.LookupEnv("OWNER_GUILD_ID"); len(EnsureNumbers(guildID)) >= 17 {
+		SetOwnerGuild(guildID)
+	}
 }
 
 // addAdmin
@@ -84,6 +94,20 @@ func IsAdmin(userId string) bool {
 	return botAdmins[userId]
 }
 
+// SetOwnerGuild
+// Sets the guild that OwnerGuildOnly commands are restricted to
+// This must be set before slash commands are registered.
+func SetOwnerGuild(guildID string) {
+	ownerGuildID = guildID
+}
+
+// IsOwnerGuild
+// Check if a guild is the bot owner's home guild
+// If no owner guild has been set, no guild is the owner guild.
+func IsOwnerGuild(guildID string) bool {
+	return ownerGuildID != "" && guildID == ownerGuildID
+}
+
 // dgoLog
 // Interop for discordgo to call tinylog.
 func dgoLog(msgL, caller int, format string, log ...interface{}) {
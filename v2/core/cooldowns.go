@@ -0,0 +1,161 @@
+package core
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// cooldowns.go
+// This file contains command cooldowns, which are per user unless the command sets a CooldownScope, see CommandInfo.Cooldown
+
+// CooldownScope
+// Who shares a command's cooldown.
+type CooldownScope int
+
+const (
+	CooldownUser    CooldownScope = iota // Each user has their own cooldown
+	CooldownChannel                      // Everyone in a channel shares the cooldown
+	CooldownGuild                        // Everyone in a guild shares the cooldown; in DMs this is the same as CooldownChannel
+)
+
+// cooldowns
+// When each cooldown ends, keyed by its owner and then commandKey
+// Owners are user IDs, or channel: and guild: followed by an ID for the wider scopes, so they can't clash.
+var cooldowns = make(map[string]map[string]time.Time)
+
+// cooldownsLock
+// Guards cooldowns, since commands run concurrently.
+var cooldownsLock sync.Mutex
+
+// cooldownPruneInterval
+// How often expired cooldowns are removed, so cooldowns doesn't keep every user that ever ran a command.
+const cooldownPruneInterval = time.Minute
+
+// lastCooldownPrune
+// When expired cooldowns were last removed.
+var lastCooldownPrune time.Time
+
+// cooldownOwner
+// Returns who a command run's cooldown belongs to for the given scope, or an empty string if it can't be told.
+func cooldownOwner(scope CooldownScope, ctx *CmdContext) string {
+	switch scope {
+	case CooldownGuild:
+		if guildID := ctx.GuildID(); guildID != "" {
+			return scopedOwner(CooldownGuild, guildID)
+		}
+		fallthrough
+	case CooldownChannel:
+		return scopedOwner(CooldownChannel, ctx.ChannelID())
+	default:
+		return ctx.AuthorID()
+	}
+}
+
+// scopedOwner
+// Returns the key cooldowns are kept under for a user, channel or guild ID, depending on the scope.
+func scopedOwner(scope CooldownScope, id string) string {
+	if id == "" {
+		return ""
+	}
+	switch scope {
+	case CooldownGuild:
+		return "guild:" + id
+	case CooldownChannel:
+		return "channel:" + id
+	default:
+		return id
+	}
+}
+
+// startCooldown
+// Starts the command's cooldown for its owner, see cooldownOwner. If the owner is still on cooldown from a previous use,
+// false is returned along with how long is left to wait.
+func startCooldown(info CommandInfo, owner string) (time.Duration, bool) {
+	if info.Cooldown <= 0 || owner == "" {
+		return 0, true
+	}
+	key := commandKey(info)
+	now := time.Now()
+	cooldownsLock.Lock()
+	defer cooldownsLock.Unlock()
+	if now.Sub(lastCooldownPrune) >= cooldownPruneInterval {
+		pruneCooldowns(now)
+	}
+	if cooldowns[owner] == nil {
+		cooldowns[owner] = make(map[string]time.Time)
+	}
+	if end := cooldowns[owner][key]; now.Before(end) {
+		return end.Sub(now), false
+	}
+	cooldowns[owner][key] = now.Add(info.Cooldown)
+	return 0, true
+}
+
+// pruneCooldowns
+// Removes every cooldown that has ended. cooldownsLock must be held.
+func pruneCooldowns(now time.Time) {
+	for owner, ends := range cooldowns {
+		for key, end := range ends {
+			if !now.Before(end) {
+				delete(ends, key)
+			}
+		}
+		if len(ends) == 0 {
+			delete(cooldowns, owner)
+		}
+	}
+	lastCooldownPrune = now
+}
+
+// ClearCooldown
+// Ends a user's cooldown for a command, given by its trigger, or "parent child" for a child command.
+// Returns how many cooldowns were cleared, which is zero if the user wasn't on cooldown.
+func ClearCooldown(userID string, trigger string) int {
+	return ClearScopedCooldown(CooldownUser, userID, trigger)
+}
+
+// ClearAllCooldowns
+// Ends all of a user's cooldowns, returning how many were cleared.
+func ClearAllCooldowns(userID string) int {
+	return ClearAllScopedCooldowns(CooldownUser, userID)
+}
+
+// ClearScopedCooldown
+// Ends a cooldown for a command, where id is a user, channel or guild ID depending on the scope, see CooldownScope.
+// Returns how many cooldowns were cleared, which is zero if there was no cooldown.
+func ClearScopedCooldown(scope CooldownScope, id string, trigger string) int {
+	owner := scopedOwner(scope, id)
+	key := strings.ToLower(strings.Join(strings.Fields(trigger), " "))
+	cooldownsLock.Lock()
+	defer cooldownsLock.Unlock()
+	end, ok := cooldowns[owner][key]
+	if !ok {
+		return 0
+	}
+	delete(cooldowns[owner], key)
+	if len(cooldowns[owner]) == 0 {
+		delete(cooldowns, owner)
+	}
+	if time.Now().After(end) {
+		return 0
+	}
+	return 1
+}
+
+// ClearAllScopedCooldowns
+// Ends every cooldown kept for a user, channel or guild ID depending on the scope, returning how many were cleared.
+func ClearAllScopedCooldowns(scope CooldownScope, id string) int {
+	owner := scopedOwner(scope, id)
+	now := time.Now()
+	cooldownsLock.Lock()
+	defer cooldownsLock.Unlock()
+	cleared := 0
+	for _, end := range cooldowns[owner] {
+		if now.Before(end) {
+			cleared++
+		}
+	}
+	delete(cooldowns, owner)
+	return cleared
+}
@@ -13,11 +13,37 @@ import (
 
 var (
 	VERSION       = "2.0.0"
+	COMMIT        string
 	ENVIRONMENT   string
 	Session       *discordgo.Session
 	WorkerManager *workers.WorkerManager
 )
 
+// startTime
+// When the bot started, used to report uptime.
+var startTime = time.Now()
+
+// Uptime
+// Returns how long the bot has been running.
+func Uptime() time.Duration {
+	return time.Since(startTime)
+}
+
+// SetVersionInfo
+// Overrides the version and commit reported by the bot. Both can also be set at build time with -ldflags "-X".
+func SetVersionInfo(version, commit string) {
+	if version != "" {
+		VERSION = version
+	}
+	COMMIT = commit
+}
+
+// VersionInfo
+// Returns the version and commit the bot was built from. The commit is empty if it was never set.
+func VersionInfo() (string, string) {
+	return VERSION, COMMIT
+}
+
 func CreateSession(token string) {
 	var err error
 	Session, err = discordgo.New("Bot " + token)
@@ -25,6 +51,7 @@ func CreateSession(token string) {
 		Log.Fatalf("Unable to create session. %s", err)
 		return
 	}
+	API = Session
 	// Set Session variables
 	Session.State.MaxMessageCount = messageState
 	if IsDevEnv() {
@@ -80,6 +107,11 @@ func InitBot() {
 // Run
 // runs the bot.
 func Run() {
+	// Move commands to the triggers they were remapped to, before anything is registered under the old ones
+	loadTriggerRemaps()
+	// The saved presence is shown once the bot is ready
+	loadPresence()
+
 	// Register the event handlers
 	// TODO rewrite handler system
 	AddHandler(handleInteraction)
@@ -91,6 +123,9 @@ func Run() {
 	if err != nil {
 		Log.Fatalf("Failed to connect to Discord: %s", err)
 	}
+	// Reload scheduled jobs now that they are able to talk to Discord
+	loadScheduledJobs()
+
 	// Log that the login succeeded
 	Log.Infof("Bot logged in as \"" + Session.State.Ready.User.Username + "#" + Session.State.Ready.User.Discriminator + "\"")
 
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cron.go
+// This file contains the parser used by Schedule args, which accepts a time of day or a cron expression
+// NextRun pairs with ScheduleAfter, so recurring announcements can schedule their next run.
+
+// timeOfDayRegex
+// Matches a 24-hour time of day, such as 9:30 or 21:05.
+var timeOfDayRegex = regexp.MustCompile(`^([01]?[0-9]|2[0-3]):([0-5][0-9])$`)
+
+// cronFields
+// How many fields a standard cron expression has.
+const cronFields = 5
+
+// ParseSchedule
+// Parses a schedule given as a 24-hour HH:MM time of day, or a standard five field cron expression
+// (minute, hour, day of month, month, day of week, e.g. "0 9 * * 1-5" for 9:00 on weekdays).
+// Times of day are normalized into the equivalent daily cron expression, which is returned with the parsed schedule.
+func ParseSchedule(in string) (string, cron.Schedule, error) {
+	in = strings.Join(strings.Fields(in), " ")
+	if match := timeOfDayRegex.FindStringSubmatch(in); match != nil {
+		hour, _ := strconv.Atoi(match[1])
+		minute, _ := strconv.Atoi(match[2])
+		in = fmt.Sprintf("%d %d * * *", minute, hour)
+	}
+	schedule, err := cron.ParseStandard(in)
+	if err != nil {
+		return "", nil, fmt.Errorf("%q is not a HH:MM time or a cron expression like \"0 9 * * 1-5\": %w", in, err)
+	}
+	return in, schedule, nil
+}
+
+// validateSchedule
+// The argValidators entry for Schedule args, which normalizes times of day into cron expressions, so commands can rely on ScheduleValue.
+func validateSchedule(value string, _ *ArgInfo) (string, error) {
+	expr, _, err := ParseSchedule(value)
+	return expr, err
+}
+
+// ScheduleValue
+// Returns the value of the arg as a cron schedule, see ParseSchedule. Invalid schedules are nil.
+func (ag CommandArg) ScheduleValue() cron.Schedule {
+	_, schedule, _ := ParseSchedule(ag.StringValue())
+	return schedule
+}
+
+// NextRun
+// Returns when the arg's schedule next fires after the given time, or the zero time if the schedule is invalid.
+func (ag CommandArg) NextRun(after time.Time) time.Time {
+	schedule := ag.ScheduleValue()
+	if schedule == nil {
+		return time.Time{}
+	}
+	return schedule.Next(after)
+}
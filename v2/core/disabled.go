@@ -0,0 +1,92 @@
+package core
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// disabled.go
+// This file contains the kill switch for turning a command off everywhere at once
+
+// disabledCommands
+// The commands that are turned off in every guild, keyed by commandKey.
+var disabledCommands = make(map[string]bool)
+
+// disabledCommandsLock
+// Guards disabledCommands, since commands can be turned off while they are being run.
+var disabledCommandsLock sync.RWMutex
+
+// disabledKey
+// Returns the key a command is disabled under, given its trigger, an alias, or "parent child" for a child command.
+func disabledKey(trigger string) string {
+	key := strings.ToLower(strings.Join(strings.Fields(trigger), " "))
+	if alias, ok := commandAliases[key]; ok {
+		return alias
+	}
+	return key
+}
+
+// DisableCommandGlobally
+// Turns a command off in every guild, for both messages and slash commands. Bot admins can still use it.
+func DisableCommandGlobally(trigger string) {
+	disabledCommandsLock.Lock()
+	defer disabledCommandsLock.Unlock()
+	disabledCommands[disabledKey(trigger)] = true
+}
+
+// EnableCommandGlobally
+// Turns a command that was turned off with DisableCommandGlobally back on.
+func EnableCommandGlobally(trigger string) {
+	disabledCommandsLock.Lock()
+	defer disabledCommandsLock.Unlock()
+	delete(disabledCommands, disabledKey(trigger))
+}
+
+// IsCommandDisabledGlobally
+// Check if a command has been turned off everywhere.
+func IsCommandDisabledGlobally(trigger string) bool {
+	disabledCommandsLock.RLock()
+	defer disabledCommandsLock.RUnlock()
+	return disabledCommands[disabledKey(trigger)]
+}
+
+// GloballyDisabledCommands
+// Returns every command that has been turned off everywhere, sorted.
+func GloballyDisabledCommands() []string {
+	disabledCommandsLock.RLock()
+	defer disabledCommandsLock.RUnlock()
+	list := make([]string, 0, len(disabledCommands))
+	for key := range disabledCommands {
+		list = append(list, key)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// isDisabledFor
+// Check if a command is turned off for a user, which it never is for bot admins.
+// A child command is also off if its parent is.
+func isDisabledFor(info CommandInfo, userID string) bool {
+	disabledCommandsLock.RLock()
+	disabled := disabledCommands[commandKey(info)] || (info.IsChild && disabledCommands[strings.ToLower(info.ParentID)])
+	disabledCommandsLock.RUnlock()
+	return disabled && !IsAdmin(userID)
+}
+
+// sendBlockedNotice
+// Tells the user a command has been turned off, using the guild's blocked command message.
+// Message commands are ignored silently if the guild has none; slash commands need a response, so they get a short one.
+func sendBlockedNotice(ctx *CmdContext, info CommandInfo) {
+	msg := ""
+	if ctx.Guild != nil {
+		msg = ctx.Guild.BlockedCommandMessage(commandKey(info))
+	}
+	if msg == "" {
+		if ctx.Interaction == nil {
+			return
+		}
+		msg = ctx.Translate(MsgCommandDisabled)
+	}
+	sendNotice(ctx, msg)
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"unicode"
+)
+
+// emoji.go
+// This file contains the parser used by Emoji args, which normalizes emoji into the format reactions expect
+
+// customEmojiRegex
+// Matches a custom emoji as it appears in a message, such as <:name:id> or <a:name:id> for animated emoji.
+var customEmojiRegex = regexp.MustCompile(`^<(a?):(\w{2,32}):([0-9]{17,20})>$`)
+
+// reactionEmojiRegex
+// Matches a custom emoji already in the name:id format reactions use, which is what Emoji args are normalized into.
+var reactionEmojiRegex = regexp.MustCompile(`^\w{2,32}:[0-9]{17,20}$`)
+
+// ParseEmoji
+// Parses a unicode emoji or a custom emoji, and returns it in the format discordgo expects for reactions
+// Custom emoji become name:id, animated or not, while unicode emoji and custom emoji already in that format are returned as-is.
+func ParseEmoji(in string) (string, bool) {
+	if match := customEmojiRegex.FindStringSubmatch(in); match != nil {
+		return match[2] + ":" + match[3], true
+	}
+	if isUnicodeEmoji(in) || reactionEmojiRegex.MatchString(in) {
+		return in, true
+	}
+	return "", false
+}
+
+// validateEmoji
+// The argValidators entry for Emoji args, which normalizes the emoji into the format used when reacting, so commands can rely on EmojiValue.
+func validateEmoji(value string, _ *ArgInfo) (string, error) {
+	emoji, ok := ParseEmoji(value)
+	if !ok {
+		return "", fmt.Errorf("%q is not an emoji", value)
+	}
+	return emoji, nil
+}
+
+// isUnicodeEmoji
+// Checks whether a string is made up of a single unicode emoji sequence, including
+// skin tones, ZWJ sequences, flags, keycaps and tag sequences.
+func isUnicodeEmoji(in string) bool {
+	runes := []rune(in)
+	if len(runes) == 0 {
+		return false
+	}
+	hasSymbol := false
+	keycap := false
+	for _, r := range runes {
+		switch {
+		case r == 0x20E3: // combining enclosing keycap
+			keycap = true
+		case r == 0x200D, r == 0xFE0E, r == 0xFE0F: // ZWJ and variation selectors
+		case r >= 0x1F3FB && r <= 0x1F3FF: // skin tone modifiers
+		case r >= 0xE0020 && r <= 0xE007F: // tag sequences, used by subdivision flags
+		case r == '#', r == '*', r >= '0' && r <= '9':
+		case unicode.Is(unicode.So, r):
+			hasSymbol = true
+		default:
+			return false
+		}
+	}
+	if keycap {
+		return runes[0] == '#' || runes[0] == '*' || (runes[0] >= '0' && runes[0] <= '9')
+	}
+	// Bare digits, # and * are only emoji as part of a keycap
+	return hasSymbol && !unicode.IsDigit(runes[0]) && runes[0] != '#' && runes[0] != '*'
+}
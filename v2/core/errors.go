@@ -0,0 +1,70 @@
+package core
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// errors.go
+// This file contains the buffer of recent error reports, so they can be looked at without scrolling through DMs
+
+// maxRecentErrors
+// How many error reports are kept. Older reports are overwritten once the buffer is full.
+const maxRecentErrors = 50
+
+// maxErrorStack
+// The most bytes of a stack trace kept with an error report, to keep the buffer's memory use bounded.
+const maxErrorStack = 4096
+
+// ErrorRecord
+// A single error report.
+type ErrorRecord struct {
+	Message   string    // The report's title and the error, if there was one
+	Command   string    // The trigger of the command that was running, if known
+	GuildID   string    // The guild the error happened in, if any
+	ChannelID string    // The channel the error happened in, if any
+	UserID    string    // The user that caused the error, if any
+	Time      time.Time // When the error was reported
+	Stack     string    // The stack trace at the time of the report, truncated to maxErrorStack bytes
+}
+
+// recentErrors
+// A fixed-size ring buffer of the most recent error reports.
+var recentErrors struct {
+	sync.Mutex
+	records [maxRecentErrors]ErrorRecord
+	next    int
+	count   int
+}
+
+// recordError
+// Adds an error report to the ring buffer, overwriting the oldest report if it is full.
+func recordError(record ErrorRecord) {
+	record.Time = time.Now()
+	stack := debug.Stack()
+	if len(stack) > maxErrorStack {
+		stack = stack[:maxErrorStack]
+	}
+	record.Stack = string(stack)
+
+	recentErrors.Lock()
+	defer recentErrors.Unlock()
+	recentErrors.records[recentErrors.next] = record
+	recentErrors.next = (recentErrors.next + 1) % maxRecentErrors
+	if recentErrors.count < maxRecentErrors {
+		recentErrors.count++
+	}
+}
+
+// RecentErrors
+// Returns the most recent error reports, newest first.
+func RecentErrors() []ErrorRecord {
+	recentErrors.Lock()
+	defer recentErrors.Unlock()
+	records := make([]ErrorRecord, recentErrors.count)
+	for i := range records {
+		records[i] = recentErrors.records[(recentErrors.next-1-i+maxRecentErrors)%maxRecentErrors]
+	}
+	return records
+}
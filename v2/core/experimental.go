@@ -0,0 +1,111 @@
+package core
+
+import (
+	"strings"
+)
+
+// experimental.go
+// This file contains the gate for experimental commands, which only bot admins and a guild's beta testers can use
+
+// experimentalLabel
+// Added after an experimental command's trigger in help listings.
+const experimentalLabel = " (beta)"
+
+// SetBetaRole
+// Sets the role whose members can use experimental commands in this guild. An empty role ID removes it.
+// Experimental slash commands only show up in the guild once slash commands are registered again.
+func (g *Guild) SetBetaRole(roleID string) {
+	g.infoLock.Lock()
+	g.Info.BetaRole = roleID
+	g.infoLock.Unlock()
+	g.save()
+}
+
+// BetaRole
+// Returns the ID of the role whose members can use experimental commands, or an empty string if there is none.
+func (g *Guild) BetaRole() string {
+	g.infoLock.RLock()
+	defer g.infoLock.RUnlock()
+	return g.Info.BetaRole
+}
+
+// IsBetaTester
+// Check if a user can use experimental commands in this guild. Bot admins always can.
+func (g *Guild) IsBetaTester(userID string) bool {
+	if IsAdmin(userID) {
+		return true
+	}
+	role := g.BetaRole()
+	if role == "" || g.ID == "" {
+		return false
+	}
+	for _, id := range memberRoles(g.ID, userID) {
+		if id == role {
+			return true
+		}
+	}
+	return false
+}
+
+// isExperimental
+// Check if a command is experimental. A child command is also experimental if its parent is.
+func isExperimental(info CommandInfo) bool {
+	if info.Experimental {
+		return true
+	}
+	if !info.IsChild {
+		return false
+	}
+	parent, ok := commands[strings.ToLower(info.ParentID)]
+	return ok && parent.Info.Experimental
+}
+
+// isExperimentalTrigger
+// Check if the command with the given trigger is experimental.
+func isExperimentalTrigger(trigger string) bool {
+	return isExperimental(commands[strings.ToLower(trigger)].Info)
+}
+
+// hasBetaTesters
+// Check if anyone in a guild can use experimental commands, which is where their slash commands are registered.
+// Bot admins can use them anywhere, but they're only registered for them in the owner guild.
+func hasBetaTesters(guildID string) bool {
+	if IsOwnerGuild(guildID) {
+		return true
+	}
+	guildsLock.RLock()
+	guild, ok := Guilds[guildID]
+	guildsLock.RUnlock()
+	return ok && guild.BetaRole() != ""
+}
+
+// isHiddenFor
+// Check if a command is experimental, and the user can't use it in the guild.
+func isHiddenFor(info CommandInfo, g *Guild, userID string) bool {
+	if !isExperimental(info) || IsAdmin(userID) {
+		return false
+	}
+	return g == nil || !g.IsBetaTester(userID)
+}
+
+// HelpCommands
+// Returns the commands a user can see in a help listing for the guild, keyed by trigger.
+// Experimental commands are left out for anyone who isn't a bot admin or one of the guild's beta testers.
+func HelpCommands(g *Guild, userID string) map[string]CommandInfo {
+	list := make(map[string]CommandInfo)
+	for trigger, info := range GetCommands() {
+		if !isHiddenFor(info, g, userID) {
+			list[trigger] = info
+		}
+	}
+	return list
+}
+
+// HelpLabel
+// Returns how a command is named in a help listing, which marks experimental commands.
+func HelpLabel(info CommandInfo) string {
+	if isExperimental(info) {
+		return info.Trigger + experimentalLabel
+	}
+	return info.Trigger
+}
@@ -0,0 +1,47 @@
+package core
+
+// features.go
+// This file contains the per-guild feature flags that decide which feature commands exist in a guild
+
+// FeatureFlagSource
+// Decides whether a feature is enabled in a guild.
+type FeatureFlagSource func(guildID string, feature string) bool
+
+// featureFlagSource
+// Where feature flags are read from. Defaults to each guild's EnabledFeatures.
+var featureFlagSource FeatureFlagSource = guildFeatureEnabled
+
+// SetFeatureFlagSource
+// Replaces where feature flags are read from, e.g. to read them from a remote config service.
+// This must be set before slash commands are registered.
+func SetFeatureFlagSource(source FeatureFlagSource) {
+	featureFlagSource = source
+}
+
+// IsFeatureEnabled
+// Check if a feature is enabled in a guild. Features are never enabled outside a guild.
+func IsFeatureEnabled(guildID string, feature string) bool {
+	if guildID == "" {
+		return false
+	}
+	return featureFlagSource(guildID, feature)
+}
+
+// guildFeatureEnabled
+// The default feature flag source, which reads from the guild's EnabledFeatures.
+func guildFeatureEnabled(guildID string, feature string) bool {
+	guildsLock.RLock()
+	guild, ok := Guilds[guildID]
+	guildsLock.RUnlock()
+	if !ok {
+		return false
+	}
+	guild.infoLock.RLock()
+	defer guild.infoLock.RUnlock()
+	for _, enabled := range guild.Info.EnabledFeatures {
+		if enabled == feature {
+			return true
+		}
+	}
+	return false
+}
@@ -1,9 +1,16 @@
 package core
 
 import (
-	"github.com/bwmarrin/discordgo"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
 )
 
 // todo finish lmao
@@ -17,8 +24,19 @@ type GuildInfo struct {
 	AddedDate         int64    // The date the bot was added to the server
 	AllowedUsageIDs   []string `json:"whitelistIds"` // List of user/role Ids that a user MUST have one of in order to run any commands, including public ones
 	Prefix            string   // The bot prefix
+	PrefixChars       string   // Extra single-character prefixes, any of which also work, see SetPrefixChars
 	ModeratorIDs      []string // The list of user/role IDs allowed to run mod-only commands
+	ModRoles          []string // The list of role IDs whose members count as moderators, see SetModRoles
 	ResponseChannelID string
+	CustomCommands    map[string]CustomCommand // The list of triggers and their corresponding outputs for custom commands
+	EnabledFeatures   []string                 // The features enabled in this guild, see CommandInfo.Feature
+	Language          string                   // The language the bot's own messages are sent in, see SetLanguage
+	NoMentionHelp     bool                     // If a bare mention of the bot is ignored instead of answered with the prefix, see SetMentionHelp
+	MutedChannels     map[string]int64         // The channels the bot won't respond in, and when each mute expires as a unix time, see MuteChannel
+	BlockedMessage    string                   // The reply to a command that has been turned off, see SetBlockedCommandMessage
+	BetaRole          string                   // The role whose members can use experimental commands, see SetBetaRole
+	EnabledPatterns   []string                 // The pattern commands enabled in this guild, see AddPatternCommand
+	CustomCommandMax  int                      // The most custom commands this guild can have; zero uses the default, see SetCustomCommandLimit
 }
 
 // NewGuildInfo
@@ -28,9 +46,23 @@ func NewGuildInfo() GuildInfo {
 		AddedDate:         time.Now().Unix(),
 		Prefix:            "!",
 		ResponseChannelID: "",
+		CustomCommands:    make(map[string]CustomCommand),
 	}
 }
 
+// guildExportVersion
+// The current version of the guild export format
+// Bump this and add a step to migrateGuildExport whenever GuildInfo changes in a way old exports can't be read as-is.
+const guildExportVersion = 1
+
+// GuildExport
+// The versioned envelope a guild's configuration is exported in.
+type GuildExport struct {
+	Version int             `json:"version"`
+	GuildID string          `json:"guildId"`
+	Info    json.RawMessage `json:"info"`
+}
+
 // Guild
 // The definition of a bot guild, which includes a pointer to the discordgo.Guild,
 // it's id, and guild storage (info).
@@ -38,6 +70,7 @@ type Guild struct {
 	*discordgo.Guild
 	Info         GuildInfo
 	RegisteredAt time.Time
+	infoLock     sync.RWMutex // Guards the parts of Info that can change while commands are running
 }
 
 // Guilds
@@ -46,6 +79,10 @@ type Guild struct {
 // Otherwise, there will be information desync.
 var Guilds map[string]*Guild
 
+// guildsLock
+// Guards the Guilds map, since guilds can be added by events while commands are looking them up.
+var guildsLock sync.RWMutex
+
 // muteLock
 // A map to store mutexes for handling mutes for a server synchronously.
 var muteLock = make(map[string]*sync.Mutex)
@@ -62,10 +99,12 @@ var currentProvider GuildProvider
 // AddGuild
 // Adds a guild to the storage/initializes already stored guilds.
 func AddGuild(g *discordgo.Guild) *Guild {
+	guildsLock.Lock()
 	// If the storage provider has already loaded the guild,
 	// Then lets just add the guild pointer
 	if guild, ok := Guilds[g.ID]; ok {
 		guild.Guild = g
+		guildsLock.Unlock()
 		return guild
 	}
 	// Create a new guild with default values
@@ -74,7 +113,11 @@ func AddGuild(g *discordgo.Guild) *Guild {
 		Info:  NewGuildInfo(),
 	}
 	// Add the new guild to the map of guilds
+	if Guilds == nil {
+		Guilds = make(map[string]*Guild)
+	}
 	Guilds[g.ID] = &newGuild
+	guildsLock.Unlock()
 	// Save the guild to .json
 	// A failed save is fatal, so we can count on this being successful
 	newGuild.save()
@@ -84,6 +127,10 @@ func AddGuild(g *discordgo.Guild) *Guild {
 
 }
 
+// GetGuild
+// Returns the guild with the given ID, or a default guild for DMs (an empty ID).
+// A guild that isn't known yet, like when a message arrives before the guild's create event,
+// is registered with the default settings, so the result is never nil and settings changed on it are kept.
 func GetGuild(guildID string) *Guild {
 	// The command is being run as a dm, send back an empty guild object with default fields
 	if guildID == "" {
@@ -94,28 +141,30 @@ func GetGuild(guildID string) *Guild {
 			Info: NewGuildInfo(),
 		}
 	}
-	if guild, ok := Guilds[guildID]; ok {
+	guildsLock.RLock()
+	guild, ok := Guilds[guildID]
+	guildsLock.RUnlock()
+	if ok {
 		return guild
 	}
-	// the guild does not exist, there must be an awful
-	// f**k up if this happens
-	Log.Errorf("unable to fetch guild %s", guildID)
-	Log.Errorf("something bad must've really happened")
-	// lets just return a bare bones guild struct
-	return &Guild{
-		Guild: &discordgo.Guild{
-			ID: "",
-		},
-		Info: NewGuildInfo(),
+	Log.Warningf("guild %s was not loaded yet, registering it with the default settings", guildID)
+	g := &discordgo.Guild{ID: guildID}
+	if Session != nil && Session.State != nil {
+		if stateGuild, err := Session.State.Guild(guildID); err == nil {
+			g = stateGuild
+		}
 	}
-
+	return AddGuild(g)
 }
 
 func GuildExists(guildID string) bool {
 	if guildID == "" {
 		return false
 	}
-	if g, ok := Guilds[guildID]; ok {
+	guildsLock.RLock()
+	g, ok := Guilds[guildID]
+	guildsLock.RUnlock()
+	if ok {
 		// check to see if the guild exists via the channels thing
 		if g.Guild.Channels == nil {
 			return false
@@ -127,7 +176,10 @@ func GuildExists(guildID string) bool {
 
 // loadGuilds loads the guilds from the provider.
 func loadGuilds() {
-	Guilds = currentProvider.Load()
+	guilds := currentProvider.Load()
+	guildsLock.Lock()
+	Guilds = guilds
+	guildsLock.Unlock()
 }
 
 // SetInitProvider sets the initProvider.
@@ -145,7 +197,372 @@ func SetInitProvider(provider func() GuildProvider) {
 
 // save the guild data to the provider.
 func (g *Guild) save() {
+	g.infoLock.RLock()
+	defer g.infoLock.RUnlock()
 	currentProvider.Save(g)
 }
 
 // Guild Helpers
+
+// IsMod
+// Check if a given ID is a moderator or not
+// The ID can be listed in ModeratorIDs directly, or be a member with one of the mod roles, see SetModRoles.
+func (g *Guild) IsMod(checkID string) bool {
+	g.infoLock.RLock()
+	ids := append([]string{}, g.Info.ModeratorIDs...)
+	roles := append([]string{}, g.Info.ModRoles...)
+	g.infoLock.RUnlock()
+	for _, id := range ids {
+		if id == checkID {
+			return true
+		}
+	}
+	if len(roles) == 0 || g.ID == "" {
+		return false
+	}
+	for _, role := range memberRoles(g.ID, checkID) {
+		for _, id := range roles {
+			if id == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// memberRoles
+// Returns the role IDs of a guild member, see ResolveMember.
+func memberRoles(guildID string, userID string) []string {
+	if API == nil {
+		return nil
+	}
+	member, err := ResolveMember(guildID, userID)
+	if err != nil {
+		return nil
+	}
+	return member.Roles
+}
+
+// SetMentionHelp
+// Sets whether mentioning the bot without a command replies with the guild's prefix.
+func (g *Guild) SetMentionHelp(enabled bool) {
+	g.infoLock.Lock()
+	g.Info.NoMentionHelp = !enabled
+	g.infoLock.Unlock()
+	g.save()
+}
+
+// MentionHelpEnabled
+// Returns whether mentioning the bot without a command replies with the guild's prefix.
+func (g *Guild) MentionHelpEnabled() bool {
+	g.infoLock.RLock()
+	defer g.infoLock.RUnlock()
+	return !g.Info.NoMentionHelp
+}
+
+// Prefixes
+// Returns the guild's prefix and its extra prefix characters, see SetPrefixChars.
+func (g *Guild) Prefixes() (string, string) {
+	g.infoLock.RLock()
+	defer g.infoLock.RUnlock()
+	return g.Info.Prefix, g.Info.PrefixChars
+}
+
+// maxPrefixChars
+// The most extra prefix characters a guild can have.
+const maxPrefixChars = 10
+
+// SetPrefixChars
+// Sets characters that each work as a prefix on top of the guild's prefix, e.g: "?." to also respond to ?ping and .ping
+// Letters, digits, spaces and backticks can't be prefixes, since they would pick up normal messages, and neither can <, @ or :,
+// which start mentions and custom emoji. An empty string removes them all.
+func (g *Guild) SetPrefixChars(chars string) error {
+	seen := make(map[rune]bool)
+	var set []rune
+	for _, c := range chars {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) || unicode.IsSpace(c) || c == '`' || c == '<' || c == '@' || c == ':' || c == utf8.RuneError {
+			return fmt.Errorf("%q can't be a prefix", c)
+		}
+		if !seen[c] {
+			seen[c] = true
+			set = append(set, c)
+		}
+	}
+	if len(set) > maxPrefixChars {
+		return fmt.Errorf("a guild can have at most %d prefix characters", maxPrefixChars)
+	}
+	g.infoLock.Lock()
+	g.Info.PrefixChars = string(set)
+	g.infoLock.Unlock()
+	g.save()
+	return nil
+}
+
+// blockedCommandPlaceholder
+// Replaced with the command's trigger in a guild's blocked command message.
+const blockedCommandPlaceholder = "{command}"
+
+// SetBlockedCommandMessage
+// Sets the reply sent when someone uses a command that has been turned off, where {command} is replaced with the command's trigger
+// An empty message, the default, ignores the command silently, except for slash commands, which get a short notice only the user can see.
+func (g *Guild) SetBlockedCommandMessage(msg string) {
+	g.infoLock.Lock()
+	g.Info.BlockedMessage = msg
+	g.infoLock.Unlock()
+	g.save()
+}
+
+// BlockedCommandMessage
+// Returns the reply for a turned off command with the given trigger, or an empty string if it is ignored silently.
+func (g *Guild) BlockedCommandMessage(trigger string) string {
+	g.infoLock.RLock()
+	defer g.infoLock.RUnlock()
+	return strings.ReplaceAll(g.Info.BlockedMessage, blockedCommandPlaceholder, trigger)
+}
+
+// ModRoles
+// Returns the role IDs whose members count as moderators in this guild.
+func (g *Guild) ModRoles() []string {
+	g.infoLock.RLock()
+	defer g.infoLock.RUnlock()
+	return append([]string{}, g.Info.ModRoles...)
+}
+
+// SetModRoles
+// Replaces the roles whose members count as moderators in this guild.
+func (g *Guild) SetModRoles(roleIDs []string) {
+	g.infoLock.Lock()
+	g.Info.ModRoles = append([]string{}, roleIDs...)
+	g.infoLock.Unlock()
+	g.save()
+}
+
+// AddModRole
+// Makes members of a role count as moderators in this guild.
+func (g *Guild) AddModRole(roleID string) error {
+	g.infoLock.Lock()
+	for _, id := range g.Info.ModRoles {
+		if id == roleID {
+			g.infoLock.Unlock()
+			return errors.New("the provided role is already a mod role")
+		}
+	}
+	g.Info.ModRoles = append(g.Info.ModRoles, roleID)
+	g.infoLock.Unlock()
+	g.save()
+	return nil
+}
+
+// RemoveModRole
+// Stops members of a role from counting as moderators in this guild.
+func (g *Guild) RemoveModRole(roleID string) error {
+	g.infoLock.Lock()
+	for i, id := range g.Info.ModRoles {
+		if id == roleID {
+			g.Info.ModRoles = append(g.Info.ModRoles[:i], g.Info.ModRoles[i+1:]...)
+			g.infoLock.Unlock()
+			g.save()
+			return nil
+		}
+	}
+	g.infoLock.Unlock()
+	return errors.New("the provided role is not a mod role")
+}
+
+// ExportJSON
+// Exports the guild's configuration as versioned JSON, which can be loaded again with ImportJSON.
+func (g *Guild) ExportJSON() ([]byte, error) {
+	g.infoLock.RLock()
+	info, err := json.Marshal(g.Info)
+	g.infoLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(GuildExport{
+		Version: guildExportVersion,
+		GuildID: g.ID,
+		Info:    info,
+	}, "", "    ")
+}
+
+// ImportJSON
+// Replaces the guild's configuration with one created by ExportJSON, then saves the guild
+// Exports from older versions are migrated first, exports from newer versions are rejected.
+func (g *Guild) ImportJSON(data []byte) error {
+	var export GuildExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return fmt.Errorf("invalid guild export: %w", err)
+	}
+	if export.Version < 1 || export.Info == nil {
+		return errors.New("invalid guild export: missing version or info")
+	}
+	if export.Version > guildExportVersion {
+		return fmt.Errorf("guild export version %d is newer than this bot supports (%d)", export.Version, guildExportVersion)
+	}
+	raw, err := migrateGuildExport(export.Version, export.Info)
+	if err != nil {
+		return err
+	}
+	// Start from the defaults, so fields missing from the export are still usable
+	info := NewGuildInfo()
+	if err = json.Unmarshal(raw, &info); err != nil {
+		return fmt.Errorf("invalid guild export: %w", err)
+	}
+	g.infoLock.Lock()
+	g.Info = info
+	g.infoLock.Unlock()
+	g.save()
+	return nil
+}
+
+// migrateGuildExport
+// Brings the info of an older export up to the current version
+// There is only one version so far, so there is nothing to migrate yet.
+func migrateGuildExport(version int, info json.RawMessage) (json.RawMessage, error) {
+	switch version {
+	case guildExportVersion:
+		return info, nil
+	default:
+		return nil, fmt.Errorf("no migration from guild export version %d", version)
+	}
+}
+
+// IsCustomCommand
+// Check if a given trigger is a custom command in this guild.
+func (g *Guild) IsCustomCommand(trigger string) bool {
+	_, ok := g.GetCustomCommand(trigger)
+	return ok
+}
+
+// GetCustomCommand
+// Returns the custom command for a trigger, and whether it exists in this guild.
+func (g *Guild) GetCustomCommand(trigger string) (CustomCommand, bool) {
+	g.infoLock.RLock()
+	defer g.infoLock.RUnlock()
+	command, ok := g.Info.CustomCommands[strings.ToLower(trigger)]
+	return command, ok
+}
+
+// CustomCommandCount
+// Returns the amount of custom commands in this guild.
+func (g *Guild) CustomCommandCount() int {
+	g.infoLock.RLock()
+	defer g.infoLock.RUnlock()
+	return len(g.Info.CustomCommands)
+}
+
+// DefaultCustomCommandLimit
+// The most custom commands a guild can have, unless the limit was changed.
+const DefaultCustomCommandLimit = 500
+
+// ErrCustomCommandLimit
+// Returned by AddCustomCommand when the guild already has as many custom commands as it is allowed.
+var ErrCustomCommandLimit = errors.New("this guild has reached its custom command limit")
+
+var (
+	customCommandLimit     = DefaultCustomCommandLimit
+	customCommandLimitLock sync.RWMutex
+)
+
+// SetDefaultCustomCommandLimit
+// Sets the most custom commands a guild can have, for guilds without their own limit.
+// A limit of 0 or less goes back to DefaultCustomCommandLimit.
+func SetDefaultCustomCommandLimit(limit int) {
+	if limit <= 0 {
+		limit = DefaultCustomCommandLimit
+	}
+	customCommandLimitLock.Lock()
+	customCommandLimit = limit
+	customCommandLimitLock.Unlock()
+}
+
+// SetCustomCommandLimit
+// Sets the most custom commands this guild can have, e.g: to raise it for a premium server.
+// A limit of 0 or less goes back to the default. Custom commands over a lowered limit are kept, but none can be added.
+func (g *Guild) SetCustomCommandLimit(limit int) {
+	if limit < 0 {
+		limit = 0
+	}
+	g.infoLock.Lock()
+	g.Info.CustomCommandMax = limit
+	g.infoLock.Unlock()
+	g.save()
+}
+
+// CustomCommandLimit
+// Returns the most custom commands this guild can have.
+func (g *Guild) CustomCommandLimit() int {
+	g.infoLock.RLock()
+	limit := g.Info.CustomCommandMax
+	g.infoLock.RUnlock()
+	if limit > 0 {
+		return limit
+	}
+	customCommandLimitLock.RLock()
+	defer customCommandLimitLock.RUnlock()
+	return customCommandLimit
+}
+
+// AddCustomCommand
+// Add a custom command to this guild. An error wrapping ErrCustomCommandLimit is returned if the guild is at its limit.
+func (g *Guild) AddCustomCommand(trigger string, content string, public bool) error {
+	trigger = strings.ToLower(trigger)
+	if _, ok := commands[trigger]; ok {
+		return errors.New("custom command would have overridden a core command")
+	}
+	limit := g.CustomCommandLimit()
+	g.infoLock.Lock()
+	if _, ok := g.Info.CustomCommands[trigger]; ok {
+		g.infoLock.Unlock()
+		return errors.New("the provided trigger is already a custom command")
+	}
+	if len(g.Info.CustomCommands) >= limit {
+		g.infoLock.Unlock()
+		return fmt.Errorf("%w of %d", ErrCustomCommandLimit, limit)
+	}
+	if g.Info.CustomCommands == nil {
+		g.Info.CustomCommands = make(map[string]CustomCommand)
+	}
+	g.Info.CustomCommands[trigger] = CustomCommand{
+		Content:     content,
+		InvokeCount: 0,
+		Public:      public,
+	}
+	g.infoLock.Unlock()
+	g.save()
+	return nil
+}
+
+// RemoveCustomCommand
+// Remove a custom command from this guild.
+func (g *Guild) RemoveCustomCommand(trigger string) error {
+	trigger = strings.ToLower(trigger)
+	g.infoLock.Lock()
+	if _, ok := g.Info.CustomCommands[trigger]; !ok {
+		g.infoLock.Unlock()
+		return errors.New("the provided trigger is not a custom command")
+	}
+	delete(g.Info.CustomCommands, trigger)
+	g.infoLock.Unlock()
+	g.save()
+	return nil
+}
+
+// ReloadCustomCommands
+// Replaces the guild's custom commands with the ones in storage, for when the stored config was edited by hand.
+func (g *Guild) ReloadCustomCommands() error {
+	if currentProvider.LoadGuild == nil {
+		return errors.New("the guild provider is unable to load a single guild")
+	}
+	info, err := currentProvider.LoadGuild(g.ID)
+	if err != nil {
+		return err
+	}
+	if info.CustomCommands == nil {
+		info.CustomCommands = make(map[string]CustomCommand)
+	}
+	g.infoLock.Lock()
+	g.Info.CustomCommands = info.CustomCommands
+	g.infoLock.Unlock()
+	return nil
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestGetGuildUnknown(t *testing.T) {
+	mock := useMockSession(t)
+	oldGuilds, oldProvider := Guilds, currentProvider
+	Guilds = nil
+	saved := 0
+	currentProvider = GuildProvider{Save: func(*Guild) { saved++ }}
+	t.Cleanup(func() {
+		Guilds, currentProvider = oldGuilds, oldProvider
+	})
+
+	g := GetGuild("200000000000000000")
+	if g == nil {
+		t.Fatal("expected a guild for an unknown guild ID, got nil")
+	}
+	if g.ID != "200000000000000000" || g.Info.Prefix != "!" {
+		t.Errorf("expected a default guild with the requested ID, got ID %q and prefix %q", g.ID, g.Info.Prefix)
+	}
+	if saved != 1 {
+		t.Errorf("expected the new guild to be saved once, got %d saves", saved)
+	}
+	if again := GetGuild("200000000000000000"); again != g {
+		t.Errorf("expected the new guild to be cached")
+	}
+
+	// A message from another unknown guild shouldn't panic in the handler
+	commandHandler(Session, &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        "1",
+		ChannelID: "2",
+		GuildID:   "300000000000000000",
+		Content:   "!unknowncommand",
+		Author:    &discordgo.User{ID: "3"},
+	}})
+	if _, ok := Guilds["300000000000000000"]; !ok {
+		t.Errorf("expected the handler to register the unknown guild")
+	}
+	if len(mock.sent) != 0 {
+		t.Errorf("expected nothing to be sent, got %+v", mock.sent)
+	}
+}
+
+func TestCustomCommandLimit(t *testing.T) {
+	oldProvider := currentProvider
+	currentProvider = GuildProvider{Save: func(*Guild) {}}
+	SetDefaultCustomCommandLimit(2)
+	t.Cleanup(func() {
+		currentProvider = oldProvider
+		SetDefaultCustomCommandLimit(0)
+	})
+
+	g := &Guild{Guild: &discordgo.Guild{ID: "1"}, Info: NewGuildInfo()}
+	for _, trigger := range []string{"one", "two"} {
+		if err := g.AddCustomCommand(trigger, "hi", true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.AddCustomCommand("three", "hi", true); !errors.Is(err, ErrCustomCommandLimit) {
+		t.Fatalf("expected ErrCustomCommandLimit, got %v", err)
+	}
+	g.SetCustomCommandLimit(3)
+	if err := g.AddCustomCommand("three", "hi", true); err != nil {
+		t.Fatalf("expected a raised limit to allow another command, got %v", err)
+	}
+	if g.CustomCommandCount() != 3 || g.CustomCommandLimit() != 3 {
+		t.Errorf("expected 3 of 3 custom commands, got %d of %d", g.CustomCommandCount(), g.CustomCommandLimit())
+	}
+}
+
+func TestPrefixesConcurrent(t *testing.T) {
+	useMockSession(t)
+	oldProvider := currentProvider
+	currentProvider = GuildProvider{Save: func(*Guild) {}}
+	t.Cleanup(func() { currentProvider = oldProvider })
+
+	g := &Guild{Guild: &discordgo.Guild{ID: "1"}, Info: NewGuildInfo()}
+	export, err := g.ExportJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = g.SetPrefixChars("?.")
+			_ = g.ImportJSON(export)
+		}
+	}()
+	// Run with -race to catch reads that aren't under the guild's lock
+	for i := 0; i < 100; i++ {
+		prefix, prefixChars := g.Prefixes()
+		ExtractCommand(prefix, prefixChars, "?ping")
+	}
+	wg.Wait()
+}
@@ -1,29 +1,98 @@
 package core
 
+import (
+	"sort"
+	"sync"
+)
+
 // handlers.go
 // Everything required for commands to pass their own handlers to discordgo
 
+// eventHandler
+// A handler registered through AddEventHandler, along with the function that removes it from the session.
+type eventHandler struct {
+	handler interface{}
+	remove  func()
+}
+
 // handlers
-// This list stores all the handlers that can be added to the bot
-// It's basically a pass-through for discordgo.AddHandler, but having a list
-// allows them to be collected ahead of time and then added all at once.
-var handlers []interface{}
+// This map stores all the handlers that can be added to the bot, keyed by the ID AddEventHandler returned
+// It's basically a pass-through for discordgo.AddHandler, but tracking them
+// allows them to be collected ahead of time, added all at once, and removed later.
+var (
+	handlers      = make(map[int]*eventHandler)
+	handlersLock  sync.Mutex
+	nextHandlerID int
+	handlersAdded bool
+)
 
 // AddHandler
 // This provides a way for commands to pass handler functions through to discorgo,
 // and have them added properly during bot startup.
 func AddHandler(handler interface{}) {
-	handlers = append(handlers, handler)
+	AddEventHandler(handler)
+}
+
+// AddEventHandler
+// Registers a handler for gateway events, which is passed through to discordgo.AddHandler
+// Handlers added before the bot starts are registered during startup, later ones are registered right away.
+// Returns an ID that can be passed to RemoveEventHandler.
+func AddEventHandler(handler interface{}) int {
+	handlersLock.Lock()
+	defer handlersLock.Unlock()
+	nextHandlerID++
+	h := &eventHandler{handler: handler}
+	if handlersAdded {
+		h.remove = Session.AddHandler(handler)
+	}
+	handlers[nextHandlerID] = h
+	return nextHandlerID
+}
+
+// RemoveEventHandler
+// Removes a handler added with AddEventHandler, returning false if there was no handler with that ID.
+func RemoveEventHandler(id int) bool {
+	handlersLock.Lock()
+	defer handlersLock.Unlock()
+	h, ok := handlers[id]
+	if !ok {
+		return false
+	}
+	if h.remove != nil {
+		h.remove()
+	}
+	delete(handlers, id)
+	return true
+}
+
+// EventHandlers
+// Returns the IDs of every registered event handler, in the order they were added.
+func EventHandlers() []int {
+	handlersLock.Lock()
+	defer handlersLock.Unlock()
+	return handlerIDs()
+}
+
+// handlerIDs
+// Returns the sorted IDs of the handlers map, the caller must hold handlersLock.
+func handlerIDs() []int {
+	ids := make([]int, 0, len(handlers))
+	for id := range handlers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
 }
 
 // addHandlers
 // Given all the handlers that have been pre-added to the handlers list, add them to the discordgo session.
 func addHandlers() {
-	if len(handlers) == 0 {
-		return
-	}
-
-	for _, handler := range handlers {
-		Session.AddHandler(handler)
+	handlersLock.Lock()
+	defer handlersLock.Unlock()
+	handlersAdded = true
+	for _, id := range handlerIDs() {
+		if h := handlers[id]; h.remove == nil {
+			h.remove = Session.AddHandler(h.handler)
+		}
 	}
 }
@@ -1,10 +1,19 @@
 package core
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
 	"github.com/bwmarrin/discordgo"
 	"github.com/ubergeek77/uberbot/internal"
-	"runtime"
-	"strings"
 )
 
 // -- Types and Structs --
@@ -25,7 +34,8 @@ var applicationCommandTypes = map[ArgTypeGuards]discordgo.ApplicationCommandOpti
 // todo add documentation
 
 type InteractionInfo struct {
-	Id string
+	Id     string
+	UserID string // If set, only this user can use the handler; everyone else is told it isn't for them
 }
 
 type InteractionCtx struct {
@@ -37,90 +47,449 @@ type InteractionCtx struct {
 type InteractionFunc func(ctx *InteractionCtx)
 
 type InteractionHandler struct {
-	Info     InteractionInfo
-	Function InteractionFunc
+	Info         InteractionInfo
+	Function     InteractionFunc
+	RegisteredAt time.Time // When the handler was added
+	ExpiresAt    time.Time // When a temporary handler is removed; zero for permanent handlers
 }
 
 var interactionHandlers = make(map[string]InteractionHandler)
 
+// interactionHandlersLock
+// Guards interactionHandlers, since temporary handlers are added and removed while the bot is running.
+var interactionHandlersLock sync.RWMutex
+
 // AddInteractHandler
 // Add a interaction handler to the bot
 func AddInteractHandler(info *InteractionInfo, function InteractionFunc) {
+	addInteractHandler(info, function, time.Time{})
+}
+
+// AddTemporaryInteractHandler
+// Adds an interaction handler that is removed after ttl, for components that only work for a while
+// (e.g: a confirmation button). Once it expires, the component is treated like it has no handler.
+func AddTemporaryInteractHandler(info *InteractionInfo, function InteractionFunc, ttl time.Duration) {
+	expires := time.Now().Add(ttl)
+	addInteractHandler(info, function, expires)
+	id := strings.ToLower(info.Id)
+	time.AfterFunc(ttl, func() {
+		interactionHandlersLock.Lock()
+		defer interactionHandlersLock.Unlock()
+		// The handler may have been replaced since, which shouldn't be removed
+		if handler, ok := interactionHandlers[id]; ok && handler.ExpiresAt.Equal(expires) {
+			delete(interactionHandlers, id)
+		}
+	})
+}
+
+// addInteractHandler
+// Adds an interaction handler, which expires at the given time unless it is zero.
+func addInteractHandler(info *InteractionInfo, function InteractionFunc, expires time.Time) {
 	interact := InteractionHandler{
-		Info:     *info,
-		Function: function,
+		Info:         *info,
+		Function:     function,
+		RegisteredAt: time.Now(),
+		ExpiresAt:    expires,
 	}
+	interactionHandlersLock.Lock()
 	interactionHandlers[strings.ToLower(info.Id)] = interact
+	interactionHandlersLock.Unlock()
+}
+
+// GetInteractHandlers
+// Returns every interaction handler, permanent and temporary, sorted by custom ID
+// The functions are left out, so the handlers can't be run or changed through the list.
+func GetInteractHandlers() []InteractionHandler {
+	interactionHandlersLock.RLock()
+	defer interactionHandlersLock.RUnlock()
+	list := make([]InteractionHandler, 0, len(interactionHandlers))
+	for _, handler := range interactionHandlers {
+		handler.Function = nil
+		list = append(list, handler)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Info.Id < list[j].Info.Id
+	})
+	return list
+}
+
+// InvokerID
+// Returns the ID of the user who triggered the interaction, whether it happened in a guild or a DM.
+func (ctx *InteractionCtx) InvokerID() string {
+	if user := interactionUser(ctx.Interaction); user != nil {
+		return user.ID
+	}
+	return ""
+}
+
+// RestrictToUser
+// Check if the interaction was triggered by the given user. If it wasn't, the user is sent an ephemeral
+// "this isn't for you" response, leaving the original message untouched, and false is returned.
+func (ctx *InteractionCtx) RestrictToUser(id string) bool {
+	if ctx.InvokerID() == id {
+		return true
+	}
+	err := API.InteractionRespond(ctx.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: Translate(GetGuild(ctx.GuildID), MsgNotForYou),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		Log.Errorf("unable to reject interaction %s: %s", ctx.ID, err)
+	}
+	return false
+}
+
+// UpdateMessage
+// Responds to a component by editing the message it is on, instead of sending a new one.
+// Nil or empty components leave the message's components as they are.
+func (ctx *InteractionCtx) UpdateMessage(content string, components []discordgo.MessageComponent) error {
+	return sendWithRetry(func() error {
+		return API.InteractionRespond(ctx.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Content:    content,
+				Components: components,
+				// Component handlers can't opt in to more mentions, so they get the defaults
+				AllowedMentions: (*CmdContext)(nil).allowedMentions(),
+			},
+		})
+	})
+}
+
+// DeferUpdate
+// Acknowledges a component without changing the message it is on, for handlers that need more than three seconds.
+// Use it when the handler will change the message the component is on, e.g: turning a page; call EditDeferred once it's ready.
+// Use DeferReply instead when the handler will answer with a new message.
+func (ctx *InteractionCtx) DeferUpdate() error {
+	return sendWithRetry(func() error {
+		return API.InteractionRespond(ctx.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredMessageUpdate,
+		})
+	})
+}
+
+// DeferReply
+// Acknowledges a component by showing a "thinking" message, which the handler replaces with EditDeferred once it's ready.
+// Use it when the handler will answer with a new message, seen only by the user who clicked if ephemeral is set.
+func (ctx *InteractionCtx) DeferReply(ephemeral bool) error {
+	var flags discordgo.MessageFlags
+	if ephemeral {
+		flags = discordgo.MessageFlagsEphemeral
+	}
+	return sendWithRetry(func() error {
+		return API.InteractionRespond(ctx.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: flags},
+		})
+	})
+}
+
+// EditDeferred
+// Finishes a deferred component interaction. After DeferUpdate this edits the message the component is on,
+// and after DeferReply it replaces the "thinking" message. Nil components leave the message's components as they are.
+func (ctx *InteractionCtx) EditDeferred(content string, components []discordgo.MessageComponent) error {
+	edit := &discordgo.WebhookEdit{
+		Content:         &content,
+		AllowedMentions: (*CmdContext)(nil).allowedMentions(),
+	}
+	if components != nil {
+		edit.Components = &components
+	}
+	return sendWithRetry(func() error {
+		_, err := API.InteractionResponseEdit(ctx.Interaction, edit)
+		return err
+	})
 }
 
 // createApplicationCommandStruct
 // Creates a slash command struct
 // todo work on sub command stuff.
 func createApplicationCommandStruct(info *CommandInfo) (st *discordgo.ApplicationCommand) {
-	if info.Arguments == nil || len(info.Arguments.Keys()) < 1 {
-		st = &discordgo.ApplicationCommand{
-			Name:        info.Trigger,
-			Description: info.Description,
-		}
-		return
+	return &discordgo.ApplicationCommand{
+		Name:        strings.ToLower(info.Trigger),
+		Description: commandDescription(info),
+		Options:     createOptions(info),
 	}
-	st = &discordgo.ApplicationCommand{
-		Name:        info.Trigger,
-		Description: info.Description,
-		Options:     make([]*discordgo.ApplicationCommandOption, len(info.Arguments.Keys())),
+}
+
+// commandDescription
+// Returns the description to register for a command
+// Discord rejects commands without a description, which would fail the whole bulk overwrite.
+func commandDescription(info *CommandInfo) string {
+	if info.Description == "" {
+		Log.Warningf("Command %s has no description, using its trigger instead", info.Trigger)
+		return info.Trigger
 	}
-	for i, k := range info.Arguments.Keys() {
+	return info.Description
+}
+
+// createOptions
+// Creates the slash command options for a command's args.
+func createOptions(info *CommandInfo) []*discordgo.ApplicationCommandOption {
+	if info.Arguments == nil || len(info.Arguments.Keys()) < 1 {
+		return nil
+	}
+	options := make([]*discordgo.ApplicationCommandOption, 0, len(info.Arguments.Keys()))
+	for _, k := range info.Arguments.Keys() {
 		v, _ := info.Arguments.Get(k)
 		vv := v.(*ArgInfo)
 		var sType discordgo.ApplicationCommandOptionType
 		if val, ok := applicationCommandTypes[vv.TypeGuard]; ok {
 			sType = val
 		} else {
-			sType = applicationCommandTypes["String"]
+			sType = applicationCommandTypes[String]
+		}
+		// Discord rejects options without a description, which would fail the whole bulk overwrite
+		optionDescription := vv.Description
+		if optionDescription == "" {
+			Log.Warningf("Argument %s on command %s has no description, using its name instead", k, info.Trigger)
+			optionDescription = k
 		}
 		optionStruct := discordgo.ApplicationCommandOption{
 			Type:        sType,
 			Name:        k,
-			Description: vv.Description,
-			Required:    vv.Required,
-		}
-		if vv.Choices != nil {
-			optionStruct.Choices = make([]*discordgo.ApplicationCommandOptionChoice, len(vv.Choices))
-			for i, k := range vv.Choices {
-				optionStruct.Choices[i] = &discordgo.ApplicationCommandOptionChoice{
-					Name:  k,
-					Value: k,
-				}
+			Description: optionDescription,
+			Required:    vv.Required && !vv.DefaultToInvoker,
+		}
+		// Discord only accepts length limits on string options
+		if sType == discordgo.ApplicationCommandOptionString {
+			if vv.MinLength > 0 {
+				minLength := vv.MinLength
+				optionStruct.MinLength = &minLength
 			}
+			optionStruct.MaxLength = vv.MaxLength
 		}
-		st.Options[i] = &optionStruct
+		if sType == discordgo.ApplicationCommandOptionChannel {
+			optionStruct.ChannelTypes = vv.ChannelTypes
+		}
+		optionStruct.Choices = createChoices(info.Trigger, k, vv)
+		// Repeated args are registered as numbered options, since slash commands can't take lists
+		if vv.Repeat > 0 {
+			for n := 1; n <= vv.Repeat; n++ {
+				repeated := optionStruct
+				repeated.Name = k + strconv.Itoa(n)
+				repeated.Required = vv.Required && n == 1
+				options = append(options, &repeated)
+			}
+			continue
+		}
+		options = append(options, &optionStruct)
 	}
-	return st
+	return options
+}
+
+// maxChoices
+// The most choices Discord allows on a single option.
+const maxChoices = 25
+
+// createChoices
+// Creates the choices for an option from its static choices and its ChoicesFunc, capped at maxChoices.
+func createChoices(trigger string, name string, arg *ArgInfo) []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, k := range arg.Choices {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  k,
+			Value: k,
+		})
+	}
+	for _, choice := range arg.ChoiceList {
+		choices = append(choices, createChoice(choice))
+	}
+	if arg.ChoicesFunc != nil {
+		for _, choice := range arg.ChoicesFunc() {
+			choices = append(choices, createChoice(choice))
+		}
+	}
+	if len(choices) > maxChoices {
+		Log.Warningf("Argument %s on command %s has %d choices, only the first %d will be registered", name, trigger, len(choices), maxChoices)
+		choices = choices[:maxChoices]
+	}
+	return choices
+}
+
+// createChoice
+// Creates the slash command choice for an ArgChoice.
+func createChoice(choice ArgChoice) *discordgo.ApplicationCommandOptionChoice {
+	return &discordgo.ApplicationCommandOptionChoice{
+		Name:              choice.Name,
+		Value:             choice.Value,
+		NameLocalizations: choice.NameLocalizations,
+	}
+}
+
+// hasChoicesFunc
+// Check if any of a command's args have choices that are only known at registration time.
+func hasChoicesFunc(info *CommandInfo) bool {
+	if info.Arguments == nil {
+		return false
+	}
+	for _, k := range info.Arguments.Keys() {
+		v, _ := info.Arguments.Get(k)
+		if v.(*ArgInfo).ChoicesFunc != nil {
+			return true
+		}
+	}
+	return false
 }
 
-// Creates a chatinput subcmd struct.
+// createChatInputSubCmdStruct
+// Creates a chatinput subcmd struct, with the children sorted by trigger so the command is the same on every start.
+// A child whose first arg is a SubCmdGrp becomes a subcommand group, holding the children registered under it
+// with both triggers as their ParentID (e.g: "config roles"), so they can't be confused with another command's children.
 func createChatInputSubCmdStruct(info *CommandInfo, childCmds map[string]Command) (st *discordgo.ApplicationCommand) {
 	st = &discordgo.ApplicationCommand{
-		Name:        info.Trigger,
-		Description: info.Description,
-		Options:     make([]*discordgo.ApplicationCommandOption, len(childCmds)),
+		Name:        strings.ToLower(info.Trigger),
+		Description: commandDescription(info),
+		Options:     make([]*discordgo.ApplicationCommandOption, 0, len(childCmds)),
 	}
-	currentPos := 0
-	for _, v := range childCmds {
-		// Stupid inline thing
-		if ar, _ := v.Info.Arguments.Get(v.Info.Arguments.Keys()[0]); ar.(*ArgInfo).TypeGuard == SubCmdGrp {
-
-		} else {
-			//Pixel:
-			//Yes I know this is O(N^2). Most likely I could get something better
-			//todo: refactor so this isn't as bad for performance
-			st.Options[currentPos] = v.Info.CreateAppOptSt()
-			currentPos++
+	for _, child := range sortedChildren(childCmds) {
+		if child.Info.MessageOnly {
+			continue
+		}
+		if !isSubCmdGroup(&child.Info) {
+			st.Options = append(st.Options, child.Info.CreateAppOptSt())
+			continue
+		}
+		grandchildren := sortedChildren(childCommands[strings.ToLower(info.Trigger)+" "+strings.ToLower(child.Info.Trigger)])
+		group := &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+			Name:        strings.ToLower(child.Info.Trigger),
+			Description: commandDescription(&child.Info),
+			Options:     make([]*discordgo.ApplicationCommandOption, 0, len(grandchildren)),
+		}
+		for _, grandchild := range grandchildren {
+			if grandchild.Info.MessageOnly {
+				continue
+			}
+			group.Options = append(group.Options, grandchild.Info.CreateAppOptSt())
 		}
+		st.Options = append(st.Options, group)
 	}
 	return st
 }
 
+// sortedChildren
+// Returns the child commands sorted by trigger.
+func sortedChildren(childCmds map[string]Command) []Command {
+	sorted := make([]Command, 0, len(childCmds))
+	for _, child := range childCmds {
+		sorted = append(sorted, child)
+	}
+	sort.Slice(sorted, func(a, b int) bool {
+		return sorted[a].Info.Trigger < sorted[b].Info.Trigger
+	})
+	return sorted
+}
+
+// isSubCmdGroup
+// Check if a child command is a subcommand group, which is marked by its first arg being a SubCmdGrp.
+func isSubCmdGroup(info *CommandInfo) bool {
+	if info.Arguments == nil || len(info.Arguments.Keys()) == 0 {
+		return false
+	}
+	arg, _ := info.Arguments.Get(info.Arguments.Keys()[0])
+	return arg.(*ArgInfo).TypeGuard == SubCmdGrp
+}
+
+// maxDescriptionLength
+// The longest description Discord accepts for a slash command or option.
+const maxDescriptionLength = 100
+
+// maxOptions
+// The most options Discord allows on a command or subcommand.
+const maxOptions = 25
+
+// nameRegex
+// The names Discord accepts for slash commands and options.
+var nameRegex = regexp.MustCompile(`^[-_\p{L}\p{N}]{1,32}$`)
+
+// ValidateCommands
+// Checks every slash command against Discord's limits, returning an error for each problem found.
+func ValidateCommands() []error {
+	var errs []error
+	for _, cmd := range buildSlashCommands() {
+		setCmd := cmd
+		if err := validateApplicationCommand(&setCmd); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// validateApplicationCommand
+// Checks a single slash command, and all of its options, against Discord's limits.
+func validateApplicationCommand(cmd *discordgo.ApplicationCommand) error {
+	// Only chat input commands have descriptions
+	if cmd.Type != 0 && cmd.Type != discordgo.ChatApplicationCommand {
+		return nil
+	}
+	if err := validateName(cmd.Name); err != nil {
+		return fmt.Errorf("command %s: %w", cmd.Name, err)
+	}
+	if err := validateDescription(cmd.Description); err != nil {
+		return fmt.Errorf("command %s: %w", cmd.Name, err)
+	}
+	return validateOptions(cmd.Name, cmd.Options)
+}
+
+// validateOptions
+// Recursively checks slash command options against Discord's limits.
+func validateOptions(path string, options []*discordgo.ApplicationCommandOption) error {
+	if len(options) > maxOptions {
+		return fmt.Errorf("command %s: has %d options, but the limit is %d", path, len(options), maxOptions)
+	}
+	optional := ""
+	for _, option := range options {
+		optionPath := path + " " + option.Name
+		// Discord rejects required options that come after optional ones
+		if !option.Required {
+			if optional == "" {
+				optional = option.Name
+			}
+		} else if optional != "" {
+			return fmt.Errorf("command %s: required option comes after optional option %s", optionPath, optional)
+		}
+		if err := validateName(option.Name); err != nil {
+			return fmt.Errorf("command %s: %w", optionPath, err)
+		}
+		if err := validateDescription(option.Description); err != nil {
+			return fmt.Errorf("command %s: %w", optionPath, err)
+		}
+		if err := validateOptions(optionPath, option.Options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateName
+// Checks a command or option name is one Discord accepts. Option names aren't lowercased for us,
+// since args are looked up by the name Discord sends back.
+func validateName(name string) error {
+	if !nameRegex.MatchString(name) {
+		return fmt.Errorf("name %q must be 1 to 32 letters, numbers, dashes or underscores, with no spaces", name)
+	}
+	if name != strings.ToLower(name) {
+		return fmt.Errorf("name %q must be lowercase", name)
+	}
+	return nil
+}
+
+// validateDescription
+// Checks a description is not empty, and not longer than Discord allows.
+func validateDescription(description string) error {
+	if description == "" {
+		return errors.New("description is empty")
+	}
+	if utf8.RuneCountInString(description) > maxDescriptionLength {
+		return fmt.Errorf("description is longer than %d characters", maxDescriptionLength)
+	}
+	return nil
+}
+
 // -- Interaction Handlers --
 
 // handleInteraction
@@ -131,17 +500,63 @@ func handleInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		handleInteractionCommand(s, i)
 		break
 	case discordgo.InteractionMessageComponent:
+		// Components have no command to opt in, so they are never used by bots
+		if isBotInteraction(i.Interaction) {
+			return
+		}
 		handleMessageComponents(s, i)
 	}
 	return
 }
 
+// isBotInteraction
+// Check if an interaction was triggered by a bot or webhook.
+func isBotInteraction(i *discordgo.Interaction) bool {
+	user := interactionUser(i)
+	return user != nil && user.Bot
+}
+
+// interactionUser
+// Returns the user who triggered an interaction. In guilds they come with their member, and in DMs on their own.
+func interactionUser(i *discordgo.Interaction) *discordgo.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// applicationCommandData
+// Returns the data of an application command interaction. Unlike ApplicationCommandData, an interaction
+// with some other kind of data is logged and reported as not ok, instead of panicking.
+func applicationCommandData(i *discordgo.Interaction) (discordgo.ApplicationCommandInteractionData, bool) {
+	data, ok := i.Data.(discordgo.ApplicationCommandInteractionData)
+	if !ok {
+		Log.Errorf("Interaction %s was routed as an application command, but has %T data", i.ID, i.Data)
+	}
+	return data, ok
+}
+
 // handleInteractionCommand
 // Handles a slash command.
 func handleInteractionCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data, ok := applicationCommandData(i.Interaction)
+	if !ok {
+		return
+	}
+	// DM interactions have no member, so the user is taken from wherever it was sent
+	user := interactionUser(i.Interaction)
+	if user == nil {
+		Log.Errorf("Interaction %s has no user, ignoring it", i.ID)
+		return
+	}
 	g := GetGuild(i.GuildID)
+	// Slash commands have to be answered, so in a muted channel the user is told privately instead
+	if mutedFor(g, i.ChannelID, user.ID) {
+		sendNotice(&CmdContext{Guild: g, Interaction: i.Interaction}, Translate(g, MsgChannelMuted))
+		return
+	}
 
-	trigger := i.ApplicationCommandData().Name
+	trigger := data.Name
 	//	// Ignore the command if it is globally disabled
 	//	if g.IsGloballyDisabled(trigger) {
 	//		ErrorResponse(i.Interaction, "Command is globally disabled", trigger)
@@ -164,88 +579,204 @@ func handleInteractionCommand(s *discordgo.Session, i *discordgo.InteractionCrea
 	//		return
 	//	}
 
+	options := data.Options
+	// Commands in a slash group are run as if they were invoked directly
+	if _, ok := slashGroups[trigger]; ok {
+		if len(options) < 1 || options[0].Type != discordgo.ApplicationCommandOptionSubCommand || !inSlashGroup(trigger, options[0].Name) {
+			return
+		}
+		trigger = options[0].Name
+		options = options[0].Options
+	}
+
 	command := commands[trigger]
-	if IsAdmin(i.Member.User.ID) || command.Info.Public || g.IsMod(i.Member.User.ID) {
+	if isBotInteraction(i.Interaction) && !command.Info.AllowBots {
+		return
+	}
+	// Owner guild, feature and guild-limited commands are only registered where they exist, but a stale registration could still reach us
+	if command.Info.OwnerGuildOnly && !IsOwnerGuild(i.GuildID) {
+		return
+	}
+	if !availableIn(command.Info, i.GuildID) {
+		return
+	}
+	if command.Info.Feature != "" && !IsFeatureEnabled(i.GuildID, command.Info.Feature) {
+		return
+	}
+	if IsAdmin(user.ID) || command.Info.Public || g.IsMod(user.ID) {
 		// Check if the command is public, or if the current user is a bot moderator
 		// Bot admins supercede both checks
 
 		defer handleInteractionError(*i.Interaction)
-		command.Function(&CmdContext{
+		ctx := &CmdContext{
 			Guild:       g,
 			Cmd:         command.Info,
-			Args:        *ParseInteractionArgs(i.ApplicationCommandData().Options),
+			Args:        *ParseResolvedInteractionArgs(options, data.Resolved),
 			Interaction: i.Interaction,
 			Message: &discordgo.Message{
 				Member:    i.Member,
-				Author:    i.Member.User,
+				Author:    user,
 				ChannelID: i.ChannelID,
 				GuildID:   i.GuildID,
 				Content:   "",
 			},
+		}
+		if command.Info.AutoAck {
+			autoAck(ctx)
+		}
+		runCommand(command, ctx)
+		return
+	}
+	sendNotice(&CmdContext{Guild: g, Cmd: command.Info, Interaction: i.Interaction}, Translate(g, MsgNoPermission))
+}
+
+// autoAck
+// Defers the interaction before the command runs, so it is acknowledged within Discord's three seconds
+// however long the command takes. Responses to the command then edit the deferred message.
+func autoAck(ctx *CmdContext) {
+	err := sendWithRetry(func() error {
+		return API.InteractionRespond(ctx.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 		})
+	})
+	if err != nil {
+		Log.Errorf("unable to defer interaction %s: %s", ctx.Interaction.ID, err)
 		return
 	}
+	ctx.acknowledged = true
+	ctx.deferPending = true
 }
 
 func handleMessageComponents(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	handlerName := i.MessageComponentData().CustomID
-	handler, ok := interactionHandlers[handlerName]
+	data, ok := i.Data.(discordgo.MessageComponentInteractionData)
 	if !ok {
-		handleInteractionError(*i.Interaction)
+		Log.Errorf("Interaction %s was routed as a message component, but has %T data", i.ID, i.Data)
+		return
+	}
+	handlerName := data.CustomID
+	interactionHandlersLock.RLock()
+	handler, ok := interactionHandlers[strings.ToLower(handlerName)]
+	interactionHandlersLock.RUnlock()
+	if !ok || handler.Function == nil {
+		Log.Warningf("No interaction handler for %s", handlerName)
+		return
 	}
 
 	defer handleInteractionError(*i.Interaction)
-	handler.Function(&InteractionCtx{
+	ctx := &InteractionCtx{
 		Info:              handler.Info,
 		InteractionCreate: i,
 		Session:           s,
-	})
+	}
+	if handler.Info.UserID != "" && !ctx.RestrictToUser(handler.Info.UserID) {
+		return
+	}
+	handler.Function(ctx)
 }
 
 // -- Slash Argument Parsing Helpers --
 
 // ParseInteractionArgs
-// Parses Interaction args.
+// Parses Interaction args
+// During autocomplete, the option the user is typing in is marked, see Arguments.Focused.
 func ParseInteractionArgs(options []*discordgo.ApplicationCommandInteractionDataOption) *map[string]CommandArg {
+	return ParseResolvedInteractionArgs(options, nil)
+}
+
+// ParseResolvedInteractionArgs
+// Parses Interaction args, attaching the users, members, roles and channels Discord resolved for them
+// so UserValue, MemberValue, RoleValue and ChannelValue don't need to look them up. Without resolved data,
+// those fall back to looking up the ID.
+func ParseResolvedInteractionArgs(options []*discordgo.ApplicationCommandInteractionDataOption, resolved *discordgo.ApplicationCommandInteractionDataResolved) *map[string]CommandArg {
 	var args = make(map[string]CommandArg)
-	for _, v := range options {
-		args[v.Name] = CommandArg{
-			info:  ArgInfo{},
-			Value: v.Value,
-		}
-		if v.Options != nil {
-			ParseInteractionArgsR(v.Options, &args)
-		}
-	}
+	parseInteractionOptions(options, resolved, &args)
 	return &args
 }
 
 // ParseInteractionArgsR
 // Parses interaction args recursively.
 func ParseInteractionArgsR(options []*discordgo.ApplicationCommandInteractionDataOption, args *map[string]CommandArg) {
+	parseInteractionOptions(options, nil, args)
+}
+
+// parseInteractionOptions
+// Adds the options, and any options nested in them, to args.
+func parseInteractionOptions(options []*discordgo.ApplicationCommandInteractionDataOption, resolved *discordgo.ApplicationCommandInteractionDataResolved, args *map[string]CommandArg) {
 	for _, v := range options {
 		(*args)[v.Name] = CommandArg{
-			info:  ArgInfo{},
-			Value: v.Value,
+			info:     ArgInfo{},
+			Value:    v.Value,
+			resolved: resolvedOption(v, resolved),
+			focused:  v.Focused,
 		}
 		if v.Options != nil {
-			ParseInteractionArgsR(v.Options, *&args)
+			parseInteractionOptions(v.Options, resolved, args)
 		}
 	}
 }
 
+// resolvedOption
+// Finds the object Discord resolved for a user, role, channel or mentionable option, or nil if there isn't one.
+// Users are given as members when the member was resolved too.
+func resolvedOption(option *discordgo.ApplicationCommandInteractionDataOption, resolved *discordgo.ApplicationCommandInteractionDataResolved) interface{} {
+	if resolved == nil {
+		return nil
+	}
+	id, ok := option.Value.(string)
+	if !ok {
+		return nil
+	}
+	switch option.Type {
+	case discordgo.ApplicationCommandOptionUser, discordgo.ApplicationCommandOptionMentionable:
+		if user, ok := resolved.Users[id]; ok {
+			if member, ok := resolved.Members[id]; ok {
+				// Resolved members don't include their user
+				m := *member
+				m.User = user
+				return &m
+			}
+			return user
+		}
+		if role, ok := resolved.Roles[id]; ok {
+			return role
+		}
+	case discordgo.ApplicationCommandOptionRole:
+		if role, ok := resolved.Roles[id]; ok {
+			return role
+		}
+	case discordgo.ApplicationCommandOptionChannel:
+		if channel, ok := resolved.Channels[id]; ok {
+			return channel
+		}
+	}
+	return nil
+}
+
 // -- :shrug: --
 
 // DeleteGuildApplicationCommands
 // Removes all guild slash commands.
+// Everything is cleared with a single bulk overwrite. If that fails, the commands are deleted one at a time,
+// waiting out any rate limit discordgo hands back instead of retrying itself.
 func DeleteGuildApplicationCommands(guildID string) {
-	commands, err := Session.ApplicationCommands(Session.State.User.ID, guildID)
+	// The slice must not be nil, nil is sent as null which Discord rejects
+	_, err := API.ApplicationCommandBulkOverwrite(Session.State.User.ID, guildID, []*discordgo.ApplicationCommand{})
+	if err == nil {
+		return
+	}
+	Log.Errorf("Unable to bulk clear slash commands in %s, deleting them individually %s", guildID, err)
+	commands, err := API.ApplicationCommands(Session.State.User.ID, guildID)
 	if err != nil {
 		Log.Errorf("Error getting all slash commands %s", err)
 		return
 	}
 	for _, k := range commands {
-		err = Session.ApplicationCommandDelete(Session.State.User.ID, guildID, k.ID)
+		err = API.ApplicationCommandDelete(Session.State.User.ID, guildID, k.ID)
+		var rateLimitErr *discordgo.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			time.Sleep(rateLimitErr.RetryAfter)
+			err = API.ApplicationCommandDelete(Session.State.User.ID, guildID, k.ID)
+		}
 		if err != nil {
 			Log.Errorf("error deleting slash command %s %s %s", k.Name, k.ID, err)
 			continue
@@ -255,24 +786,42 @@ func DeleteGuildApplicationCommands(guildID string) {
 
 func handleInteractionError(i discordgo.Interaction) {
 	if r := recover(); r != nil {
-		Log.Warningf("Recovering from panic: %s", r)
+		trigger := ""
+		if data, ok := i.Data.(discordgo.ApplicationCommandInteractionData); ok {
+			trigger = data.Name
+		}
+		defer recoverReportFailure(trigger)
+		Log.Warningf("Recovering from panic: %v\n%s", r, debug.Stack())
 		Log.Warningf("Sending Error report to admins")
-		SendErrorReport(i.GuildID, i.ChannelID, i.Member.User.ID, "Error!", r.(runtime.Error))
-		message, err := Session.InteractionResponseEdit(&i, &discordgo.WebhookEdit{
-			Content: internal.ToPtr("error executing command"),
+		userID := ""
+		if user := interactionUser(&i); user != nil {
+			userID = user.ID
+		}
+		sendErrorReport(trigger, i.GuildID, i.ChannelID, userID, "Error!", panicError(r))
+		var message *discordgo.Message
+		err := sendWithRetry(func() (err error) {
+			message, err = API.InteractionResponseEdit(&i, &discordgo.WebhookEdit{
+				Content: internal.ToPtr(Translate(GetGuild(i.GuildID), MsgInteractionError)),
+			})
+			return err
 		})
 		if err != nil {
 			Log.Errorf("err sending message %s", err)
-			err = Session.InteractionRespond(&i, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Flags:   1 << 6,
-					Content: "error executing command",
-				},
+			err = sendWithRetry(func() error {
+				return API.InteractionRespond(&i, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Flags:   1 << 6,
+						Content: Translate(GetGuild(i.GuildID), MsgInteractionError),
+					},
+				})
 			})
-			Log.Errorf("err responding to interaction %s", err.Error())
+			if err != nil {
+				Log.Errorf("err responding to interaction %s", err.Error())
+			}
+			return
 		}
-		err = Session.ChannelMessageDelete(i.ChannelID, message.ID)
+		err = API.ChannelMessageDelete(i.ChannelID, message.ID)
 		if err != nil {
 			Log.Errorf("unable to delete message %s", err.Error())
 		}
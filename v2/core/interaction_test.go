@@ -0,0 +1,353 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestCreateChatInputSubCmdStructSorted(t *testing.T) {
+	parent := CreateCommandInfo("parent", "A parent", true, Utility)
+	children := map[string]Command{}
+	for _, trigger := range []string{"zeta", "alpha", "mid", "beta", "omega"} {
+		child := CreateCommandInfo(trigger, "A child", true, Utility)
+		child.SetParent(false, "parent")
+		children[trigger] = Command{Info: *child}
+	}
+	group := CreateCommandInfo("group", "A group", true, Utility).AddArg("group", SubCmdGrp, ArgOption, "A group", false, "")
+	group.SetParent(false, "parent")
+	children["group"] = Command{Info: *group}
+	childCommands["parent group"] = map[string]Command{}
+	for _, trigger := range []string{"two", "one"} {
+		child := CreateCommandInfo(trigger, "A grandchild", true, Utility)
+		child.SetParent(false, "parent group")
+		childCommands["parent group"][trigger] = Command{Info: *child}
+	}
+	t.Cleanup(func() { delete(childCommands, "parent group") })
+
+	first := createChatInputSubCmdStruct(parent, children)
+	var names []string
+	for _, option := range first.Options {
+		names = append(names, option.Name)
+	}
+	want := []string{"alpha", "beta", "group", "mid", "omega", "zeta"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected options %v, got %v", want, names)
+	}
+
+	grp := first.Options[2]
+	if grp.Type != discordgo.ApplicationCommandOptionSubCommandGroup {
+		t.Errorf("expected group to be a subcommand group, got type %d", grp.Type)
+	}
+	if len(grp.Options) != 2 || grp.Options[0].Name != "one" || grp.Options[1].Name != "two" {
+		t.Errorf("expected group to hold one and two, got %+v", grp.Options)
+	}
+
+	// Map iteration order is random, so building it again should catch any ordering that depends on it
+	for i := 0; i < 20; i++ {
+		if again := createChatInputSubCmdStruct(parent, children); !reflect.DeepEqual(first, again) {
+			t.Fatalf("expected the same struct on every build, got %+v and %+v", first, again)
+		}
+	}
+}
+
+func TestAddSlashCommandParent(t *testing.T) {
+	oldCommands, oldChildren, oldSlash := commands, childCommands, slashCommands
+	commands, childCommands, slashCommands = make(map[string]Command), make(ChildCommand), make(map[string]discordgo.ApplicationCommand)
+	t.Cleanup(func() { commands, childCommands, slashCommands = oldCommands, oldChildren, oldSlash })
+
+	parent := CreateCommandInfo("settings", "Changes settings", true, Utility)
+	parent.SetParent(true, "")
+	AddCommand(parent, func(ctx *CmdContext) {})
+	for _, trigger := range []string{"prefix", "language"} {
+		child := CreateCommandInfo(trigger, "A setting", true, Utility)
+		child.SetParent(false, "settings")
+		AddChildCommand(child, func(ctx *CmdContext) {})
+	}
+	group := CreateCommandInfo("roles", "Role settings", true, Utility).AddArg("roles", SubCmdGrp, ArgOption, "Role settings", false, "")
+	group.SetParent(false, "settings")
+	AddChildCommand(group, func(ctx *CmdContext) {})
+	grandchild := CreateCommandInfo("mod", "Sets the mod role", true, Utility)
+	grandchild.SetParent(false, "settings roles")
+	AddChildCommand(grandchild, func(ctx *CmdContext) {})
+	// A top level parent with the same trigger as the group keeps its children to itself
+	other := CreateCommandInfo("roles", "Lists roles", true, Utility)
+	other.SetParent(true, "")
+	AddCommand(other, func(ctx *CmdContext) {})
+	otherChild := CreateCommandInfo("list", "Lists every role", true, Utility)
+	otherChild.SetParent(false, "roles")
+	AddChildCommand(otherChild, func(ctx *CmdContext) {})
+
+	AddSlashCommand(parent)
+	if added := slashCommands["settings"]; len(added.Options) != 3 || added.Options[0].Name != "language" {
+		t.Fatalf("expected the parent to be added with its subcommands, got %+v", added.Options)
+	}
+	// Children added after the parent still show up once the commands are built
+	late := CreateCommandInfo("timezone", "Sets the timezone", true, Utility)
+	late.SetParent(false, "settings")
+	AddChildCommand(late, func(ctx *CmdContext) {})
+
+	built := buildSlashCommands()["settings"]
+	var names []string
+	for _, option := range built.Options {
+		names = append(names, option.Name)
+	}
+	if want := []string{"language", "prefix", "roles", "timezone"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected subcommands %v, got %v", want, names)
+	}
+	if built.Options[0].Type != discordgo.ApplicationCommandOptionSubCommand {
+		t.Errorf("expected language to be a subcommand, got type %d", built.Options[0].Type)
+	}
+	roles := built.Options[2]
+	if roles.Type != discordgo.ApplicationCommandOptionSubCommandGroup || len(roles.Options) != 1 || roles.Options[0].Name != "mod" {
+		t.Errorf("expected roles to be a group holding only mod, got %+v", roles)
+	}
+}
+
+func TestChoiceLocalizations(t *testing.T) {
+	info := CreateCommandInfo("lang", "Picks a language", true, Utility).
+		AddArg("language", String, ArgOption, "The language", true, "").
+		AddLocalizedChoices("language", []ArgChoice{
+			{Name: "English", Value: "en", NameLocalizations: map[discordgo.Locale]string{discordgo.French: "Anglais"}},
+			{Name: "French", Value: "fr"},
+		})
+	info.SetChoicesFunc("language", func() []ArgChoice {
+		return []ArgChoice{{Name: "German", Value: "de", NameLocalizations: map[discordgo.Locale]string{discordgo.German: "Deutsch"}}}
+	})
+
+	st := createApplicationCommandStruct(info)
+	if len(st.Options) != 1 {
+		t.Fatalf("expected a single option, got %+v", st.Options)
+	}
+	choices := st.Options[0].Choices
+	if len(choices) != 3 {
+		t.Fatalf("expected 3 choices, got %+v", choices)
+	}
+	if choices[0].Name != "English" || choices[0].Value != "en" || choices[0].NameLocalizations[discordgo.French] != "Anglais" {
+		t.Errorf("expected English to keep its value and French name, got %+v", choices[0])
+	}
+	if choices[1].NameLocalizations != nil {
+		t.Errorf("expected French to have no localizations, got %+v", choices[1].NameLocalizations)
+	}
+	if choices[2].NameLocalizations[discordgo.German] != "Deutsch" {
+		t.Errorf("expected localizations from the ChoicesFunc, got %+v", choices[2])
+	}
+}
+
+func TestRepeatedArg(t *testing.T) {
+	info := CreateCommandInfo("tags", "Tags things", true, Utility).
+		AddRepeatedArg("tag", String, "A tag", true, 3)
+
+	st := createApplicationCommandStruct(info)
+	var names []string
+	for _, opt := range st.Options {
+		names = append(names, opt.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"tag1", "tag2", "tag3"}) {
+		t.Fatalf("expected numbered options, got %v", names)
+	}
+	if !st.Options[0].Required || st.Options[1].Required {
+		t.Errorf("expected only the first option to be required")
+	}
+
+	args := *ParseInteractionArgs([]*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "tag1", Type: discordgo.ApplicationCommandOptionString, Value: "a"},
+		{Name: "tag3", Type: discordgo.ApplicationCommandOptionString, Value: "c"},
+	})
+	collapseRepeatedArgs(args, info.Arguments)
+	if got := args["tag"].ListValue(); !reflect.DeepEqual(got, []string{"a", "c"}) {
+		t.Errorf("expected slash values [a c], got %v", got)
+	}
+	if _, ok := args["tag1"]; ok {
+		t.Errorf("expected the numbered options to be removed")
+	}
+
+	args = *ParseArguments("a b  c d", info.Arguments)
+	collapseRepeatedArgs(args, info.Arguments)
+	if got := args["tag"].ListValue(); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("expected message values [a b c] capped at 3, got %q", got)
+	}
+}
+
+func TestParseFocusedArg(t *testing.T) {
+	args := Arguments(*ParseInteractionArgs([]*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "sub", Type: discordgo.ApplicationCommandOptionSubCommand, Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "user", Type: discordgo.ApplicationCommandOptionString, Value: "someone"},
+			{Name: "tag", Type: discordgo.ApplicationCommandOptionString, Value: "fo", Focused: true},
+		}},
+	}))
+	name, arg, ok := args.Focused()
+	if !ok || name != "tag" || arg.StringValue() != "fo" || !arg.IsFocused() {
+		t.Fatalf("expected tag to be focused, got %q %v", name, ok)
+	}
+	if args["user"].IsFocused() {
+		t.Errorf("expected user not to be focused")
+	}
+
+	args = Arguments(*ParseInteractionArgs([]*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "user", Type: discordgo.ApplicationCommandOptionString, Value: "someone"},
+	}))
+	if _, _, ok := args.Focused(); ok {
+		t.Errorf("expected no focused option")
+	}
+}
+
+func TestFollowupEphemeral(t *testing.T) {
+	mock := useMockSession(t)
+
+	// A publicly deferred command has to replace its deferred message before it can follow up privately
+	ctx := &CmdContext{Interaction: &discordgo.Interaction{ID: "1"}, acknowledged: true, deferPending: true}
+	if err := ctx.Followup("only you can see this", true); !errors.Is(err, ErrDeferPending) {
+		t.Fatalf("expected ErrDeferPending, got %v", err)
+	}
+	if err := ctx.Followup("done!", false); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := ctx.Followup("only you can see this", true); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(mock.followups) != 2 || mock.followups[0].Flags != 0 || mock.followups[1].Flags != discordgo.MessageFlagsEphemeral {
+		t.Fatalf("expected a public then an ephemeral followup, got %+v", mock.followups)
+	}
+	if len(mock.responses) != 0 {
+		t.Errorf("expected the deferred interaction not to be answered again")
+	}
+
+	// An interaction that wasn't answered yet is answered by the followup
+	ctx = &CmdContext{Interaction: &discordgo.Interaction{ID: "2"}}
+	if err := ctx.Followup("only you can see this", true); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(mock.responses) != 1 || mock.responses[0].Data.Flags != discordgo.MessageFlagsEphemeral {
+		t.Errorf("expected an ephemeral response, got %+v", mock.responses)
+	}
+}
+
+func TestHandleInteractionWrongData(t *testing.T) {
+	mock := useMockSession(t)
+
+	// Each interaction claims to be one type, but carries the data of the other
+	for _, i := range []*discordgo.Interaction{
+		{ID: "1", Type: discordgo.InteractionApplicationCommand, Data: discordgo.MessageComponentInteractionData{CustomID: "button"}},
+		{ID: "2", Type: discordgo.InteractionMessageComponent, Data: discordgo.ApplicationCommandInteractionData{Name: "ping"}},
+		{ID: "3", Type: discordgo.InteractionApplicationCommand},
+	} {
+		handleInteraction(Session, &discordgo.InteractionCreate{Interaction: i})
+	}
+	if len(mock.responses) != 0 || len(mock.sent) != 0 {
+		t.Errorf("expected misrouted interactions to be ignored, got %+v %+v", mock.responses, mock.sent)
+	}
+}
+
+func TestHandleInteractionInDM(t *testing.T) {
+	useMockSession(t)
+	oldCommands, oldGuilds, oldProvider := commands, Guilds, currentProvider
+	commands, Guilds = make(map[string]Command), nil
+	currentProvider = GuildProvider{Save: func(*Guild) {}}
+	t.Cleanup(func() { commands, Guilds, currentProvider = oldCommands, oldGuilds, oldProvider })
+
+	var author string
+	AddCommand(CreateCommandInfo("dm", "Works in DMs", true, Utility), func(ctx *CmdContext) {
+		author = ctx.Message.Author.ID
+	})
+	// DM interactions have a user, but no member
+	handleInteraction(Session, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:        "1",
+		Type:      discordgo.InteractionApplicationCommand,
+		ChannelID: "2",
+		User:      &discordgo.User{ID: "3"},
+		Data:      discordgo.ApplicationCommandInteractionData{Name: "dm"},
+	}})
+	if author != "3" {
+		t.Errorf("expected the command to run for the DM user, got author %q", author)
+	}
+}
+
+func TestWithError(t *testing.T) {
+	mock := useMockSession(t)
+
+	fn := WithError(func(ctx *CmdContext) error {
+		if ctx.Interaction.ID == "1" {
+			return nil
+		}
+		return errors.New("the thing broke")
+	})
+	fn(&CmdContext{Interaction: &discordgo.Interaction{ID: "1"}})
+	if len(mock.responses) != 0 {
+		t.Fatalf("expected nothing to be sent without an error, got %+v", mock.responses)
+	}
+	fn(&CmdContext{Interaction: &discordgo.Interaction{ID: "2"}})
+	if len(mock.responses) != 1 {
+		t.Fatalf("expected the error to answer the interaction, got %+v", mock.responses)
+	}
+	data := mock.responses[0].Data
+	if data.Flags != discordgo.MessageFlagsEphemeral || !strings.Contains(data.Content, "the thing broke") {
+		t.Errorf("expected an ephemeral message with the error, got %+v", data)
+	}
+}
+
+func TestDeferUpdate(t *testing.T) {
+	mock := useMockSession(t)
+
+	ctx := &InteractionCtx{InteractionCreate: &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{ID: "1"}}}
+	if err := ctx.DeferUpdate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.EditDeferred("page 2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(mock.responses) != 1 || mock.responses[0].Type != discordgo.InteractionResponseDeferredMessageUpdate {
+		t.Fatalf("expected a deferred update, got %+v", mock.responses)
+	}
+	if len(mock.edits) != 1 || *mock.edits[0].Content != "page 2" || mock.edits[0].Components != nil {
+		t.Errorf("expected the message to be edited without touching its components, got %+v", mock.edits)
+	}
+
+	if err := ctx.DeferReply(true); err != nil {
+		t.Fatal(err)
+	}
+	if len(mock.responses) != 2 || mock.responses[1].Type != discordgo.InteractionResponseDeferredChannelMessageWithSource ||
+		mock.responses[1].Data.Flags != discordgo.MessageFlagsEphemeral {
+		t.Errorf("expected an ephemeral deferred reply, got %+v", mock.responses[1])
+	}
+}
+
+func TestValidateRepeatedArgOptions(t *testing.T) {
+	valid := CreateCommandInfo("tag", "Tags people", true, Utility).
+		AddArg("reason", String, ArgOption, "Why", true, "").
+		AddRepeatedArg("user", User, "Who to tag", true, 5)
+	if err := validateApplicationCommand(createApplicationCommandStruct(valid)); err != nil {
+		t.Errorf("expected a repeated arg after required args to be valid, got %s", err)
+	}
+
+	tooMany := CreateCommandInfo("tag", "Tags people", true, Utility).
+		AddArg("reason", String, ArgOption, "Why", true, "").
+		AddRepeatedArg("user", User, "Who to tag", true, 25)
+	if err := validateApplicationCommand(createApplicationCommandStruct(tooMany)); err == nil {
+		t.Errorf("expected 26 options to be rejected")
+	}
+
+	outOfOrder := CreateCommandInfo("tag", "Tags people", true, Utility).
+		AddRepeatedArg("user", User, "Who to tag", true, 3).
+		AddArg("reason", String, ArgOption, "Why", true, "")
+	if err := validateApplicationCommand(createApplicationCommandStruct(outOfOrder)); err == nil {
+		t.Errorf("expected a required option after the optional numbered options to be rejected")
+	}
+
+	longName := CreateCommandInfo("tag", "Tags people", true, Utility).
+		AddRepeatedArg("a_very_long_argument_name_here_", User, "Who to tag", false, 10)
+	if err := validateApplicationCommand(createApplicationCommandStruct(longName)); err == nil {
+		t.Errorf("expected numbered options over 32 characters to be rejected")
+	}
+}
+
+func TestCreateChatInputSubCmdStructDescription(t *testing.T) {
+	parent := CreateCommandInfo("group", "", true, Utility)
+	st := createChatInputSubCmdStruct(parent, nil)
+	if err := validateApplicationCommand(st); err != nil {
+		t.Errorf("expected the trigger to stand in for an empty description, got %s", err)
+	}
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// language.go
+// This file contains the catalogs used to translate the bot's own messages, and the language set for each guild
+
+// The keys of the bot's own messages, see Translate.
+const (
+	MsgCommandNotFound  = "command_not_found"
+	MsgNoPermission     = "no_permission"
+	MsgInvalidArguments = "invalid_arguments"
+	MsgCooldown         = "cooldown"
+	MsgCommandBusy      = "command_busy"
+	MsgNotForYou        = "not_for_you"
+	MsgCommandError     = "command_error"
+	MsgInteractionError = "interaction_error"
+	MsgResponseAsFile   = "response_as_file"
+	MsgCommandDisabled  = "command_disabled"
+	MsgMentionHelp      = "mention_help"
+	MsgChannelMuted     = "channel_muted"
+	MsgCommandFailed    = "command_failed"
+	MsgMaintenance      = "maintenance"
+)
+
+// DefaultLanguage
+// The language used for guilds that haven't set one, and for messages missing from a guild's catalog.
+const DefaultLanguage = "en"
+
+// Catalog
+// The messages for a single language, keyed by message key. Messages can use fmt verbs for the arguments given to Translate.
+type Catalog map[string]string
+
+// catalogs
+// Every language's catalog, keyed by language code.
+var catalogs = map[string]Catalog{
+	DefaultLanguage: {
+		MsgCommandNotFound:  "Error! Command not found!",
+		MsgNoPermission:     "Sorry, you don't have permission to use this command",
+		MsgInvalidArguments: "Invalid arguments: %s",
+		MsgCooldown:         "You're using this command too quickly, try again in %s",
+		MsgCommandBusy:      "This command is busy, try again shortly",
+		MsgNotForYou:        "This isn't for you",
+		MsgCommandError:     "Error!",
+		MsgInteractionError: "error executing command",
+		MsgResponseAsFile:   "The response was too long, so it has been attached as a file",
+		MsgCommandDisabled:  "This command has been turned off for now",
+		MsgMentionHelp:      "My prefix here is `%s`, try `%shelp` to see what I can do",
+		MsgChannelMuted:     "I've been muted in this channel for now",
+		MsgCommandFailed:    "Something went wrong: %s",
+		MsgMaintenance:      "The bot is under maintenance, try again later",
+	},
+}
+
+// catalogsLock
+// Guards catalogs, since catalogs can be added while commands are running.
+var catalogsLock sync.RWMutex
+
+// AddCatalog
+// Adds messages for a language, replacing any it already had. Messages that are left out fall back to English.
+func AddCatalog(language string, catalog Catalog) {
+	language = strings.ToLower(language)
+	catalogsLock.Lock()
+	defer catalogsLock.Unlock()
+	if catalogs[language] == nil {
+		catalogs[language] = make(Catalog, len(catalog))
+	}
+	for key, message := range catalog {
+		catalogs[language][key] = message
+	}
+}
+
+// Translate
+// Returns a message in the guild's language, formatted with args. A nil guild gets the default language,
+// and a key missing from every catalog is returned as-is.
+func Translate(guild *Guild, key string, args ...interface{}) string {
+	language := DefaultLanguage
+	if guild != nil {
+		language = guild.Language()
+	}
+	catalogsLock.RLock()
+	message, ok := catalogs[language][key]
+	if !ok {
+		message, ok = catalogs[DefaultLanguage][key]
+	}
+	catalogsLock.RUnlock()
+	if !ok {
+		message = key
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Translate
+// Returns a message in the language of the guild the command was run in, see Translate.
+func (ctx *CmdContext) Translate(key string, args ...interface{}) string {
+	return Translate(ctx.Guild, key, args...)
+}
+
+// SetLanguage
+// Sets the language the bot's own messages are sent in for this guild. An empty code resets it to the default.
+func (g *Guild) SetLanguage(code string) {
+	code = strings.ToLower(code)
+	catalogsLock.RLock()
+	_, ok := catalogs[code]
+	catalogsLock.RUnlock()
+	if code != "" && !ok {
+		Log.Warningf("Guild %s was set to %s, which has no catalog, so messages will be in %s", g.ID, code, DefaultLanguage)
+	}
+	g.infoLock.Lock()
+	g.Info.Language = code
+	g.infoLock.Unlock()
+	g.save()
+}
+
+// Language
+// Returns the language the bot's own messages are sent in for this guild.
+func (g *Guild) Language() string {
+	g.infoLock.RLock()
+	defer g.infoLock.RUnlock()
+	if g.Info.Language == "" {
+		return DefaultLanguage
+	}
+	return g.Info.Language
+}
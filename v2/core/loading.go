@@ -0,0 +1,94 @@
+package core
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// loading.go
+// This file contains a response that shows a disabled loading button while a command works, then replaces it with the result
+
+// loadingButtonID
+// The custom ID of the loading button. It is always disabled, so it never needs a handler.
+const loadingButtonID = "core:loading"
+
+// LoadingWork
+// The work done while the loading button is shown. It returns the content and components the message ends up with.
+type LoadingWork func() (string, []discordgo.MessageComponent, error)
+
+// ReplyLoading
+// Replies straight away with a disabled button showing label, runs work, then edits the reply to what work returned
+// If work returns an error or panics, the loading button is removed and the reply says something went wrong,
+// so it isn't left loading forever. The error is returned, and panics are passed on after the cleanup.
+func (ctx *CmdContext) ReplyLoading(label string, work LoadingWork) (err error) {
+	loading := []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: label, Style: discordgo.SecondaryButton, CustomID: loadingButtonID, Disabled: true},
+	}}}
+	finish, err := ctx.sendLoading(loading)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if cleanupErr := finish(ctx.Translate(MsgCommandError), []discordgo.MessageComponent{}); cleanupErr != nil {
+				Log.Errorf("unable to clear loading message: %s", cleanupErr)
+			}
+			panic(r)
+		}
+	}()
+	content, components, err := work()
+	if err != nil {
+		if cleanupErr := finish(ctx.Translate(MsgCommandError), []discordgo.MessageComponent{}); cleanupErr != nil {
+			Log.Errorf("unable to clear loading message: %s", cleanupErr)
+		}
+		return err
+	}
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+	return finish(content, components)
+}
+
+// sendLoading
+// Sends the loading message, returning a function that edits it into its final state.
+func (ctx *CmdContext) sendLoading(loading []discordgo.MessageComponent) (func(string, []discordgo.MessageComponent) error, error) {
+	if ctx.Interaction != nil {
+		var err error
+		if ctx.acknowledged {
+			_, err = EditInteractionResponse(ctx, &discordgo.WebhookEdit{Components: &loading})
+		} else {
+			err = sendWithRetry(func() error {
+				return API.InteractionRespond(ctx.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{Components: loading},
+				})
+			})
+		}
+		if err != nil {
+			return nil, err
+		}
+		return func(content string, components []discordgo.MessageComponent) error {
+			_, err := EditInteractionResponse(ctx, &discordgo.WebhookEdit{
+				Content:         &content,
+				Components:      &components,
+				AllowedMentions: ctx.allowedMentions(),
+			})
+			return err
+		}, nil
+	}
+	message, err := ReplyToMessage(ctx.Message, &discordgo.MessageSend{Components: loading})
+	if err != nil {
+		return nil, err
+	}
+	return func(content string, components []discordgo.MessageComponent) error {
+		return sendWithRetry(func() error {
+			_, err := API.ChannelMessageEditComplex(&discordgo.MessageEdit{
+				ID:              message.ID,
+				Channel:         message.ChannelID,
+				Content:         &content,
+				Components:      components,
+				AllowedMentions: ctx.allowedMentions(),
+			})
+			return err
+		})
+	}, nil
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"sync"
+)
+
+// maintenance.go
+// This file contains maintenance mode, which stops everyone but bot admins from using commands, e.g: before a deploy
+
+var (
+	maintenanceOn      bool
+	maintenanceMessage string
+	maintenanceLock    sync.RWMutex
+)
+
+// SetMaintenanceMode
+// Turns maintenance mode on or off. While it is on, commands only reply with the message, except for bot admins.
+// An empty message uses the default one, in each guild's language. Maintenance mode is off until this turns it on.
+func SetMaintenanceMode(on bool, message string) {
+	maintenanceLock.Lock()
+	defer maintenanceLock.Unlock()
+	maintenanceOn = on
+	maintenanceMessage = message
+	if on {
+		Log.Warningf("Maintenance mode is on, only bot admins can use commands")
+	} else {
+		Log.Infof("Maintenance mode is off")
+	}
+}
+
+// MaintenanceMode
+// Returns whether maintenance mode is on, and the message set for it.
+func MaintenanceMode() (bool, string) {
+	maintenanceLock.RLock()
+	defer maintenanceLock.RUnlock()
+	return maintenanceOn, maintenanceMessage
+}
+
+// inMaintenanceFor
+// Check if maintenance mode stops a user from using commands, which it never does for bot admins.
+func inMaintenanceFor(userID string) bool {
+	on, _ := MaintenanceMode()
+	return on && !IsAdmin(userID)
+}
+
+// sendMaintenanceNotice
+// Tells the user the bot is under maintenance.
+func sendMaintenanceNotice(ctx *CmdContext) {
+	_, msg := MaintenanceMode()
+	if msg == "" {
+		msg = ctx.Translate(MsgMaintenance)
+	}
+	sendNotice(ctx, msg)
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// members.go
+// This file contains the name lookup used by User args that have fuzzy matching enabled
+
+// fuzzyMemberLimit
+// The most members a name lookup asks Discord for.
+const fuzzyMemberLimit = 10
+
+// FindMember
+// Finds the guild member with the given username or nickname. Exact matches (ignoring case) are preferred,
+// otherwise the name can be the start of a single member's name. An error is returned if nobody matches,
+// or if more than one member does.
+func FindMember(guildID string, name string) (*discordgo.Member, error) {
+	members, err := API.GuildMembersSearch(guildID, name, fuzzyMemberLimit)
+	if err != nil {
+		return nil, err
+	}
+	var exact []*discordgo.Member
+	for _, member := range members {
+		if strings.EqualFold(member.User.Username, name) || strings.EqualFold(member.Nick, name) {
+			exact = append(exact, member)
+		}
+	}
+	matches := members
+	if len(exact) > 0 {
+		matches = exact
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no member named %s was found", name)
+	case 1:
+		return matches[0], nil
+	}
+	names := make([]string, 0, len(matches))
+	for _, member := range matches {
+		names = append(names, member.User.Username+"#"+member.User.Discriminator)
+	}
+	return nil, fmt.Errorf("%s matches more than one member (%s), use a mention or ID instead", name, strings.Join(names, ", "))
+}
+
+// resolveFuzzyMembers
+// Replaces the names given to fuzzy User args with the ID of the member they match.
+// Only message commands in a guild are resolved, since slash commands always give a user.
+func resolveFuzzyMembers(ctx *CmdContext) error {
+	if ctx.Interaction != nil || ctx.Guild == nil || ctx.Guild.ID == "" || ctx.Cmd.Arguments == nil {
+		return nil
+	}
+	for _, k := range ctx.Cmd.Arguments.Keys() {
+		v, _ := ctx.Cmd.Arguments.Get(k)
+		vv := v.(*ArgInfo)
+		if !vv.FuzzyMember || vv.TypeGuard != User {
+			continue
+		}
+		arg, ok := ctx.Args[k]
+		if !ok {
+			continue
+		}
+		name, ok := arg.Value.(string)
+		if !ok || name == "" || checkTypeGuard(name, User) {
+			continue
+		}
+		member, err := FindMember(ctx.Guild.ID, name)
+		if err != nil {
+			return err
+		}
+		arg.Value = member.User.ID
+		ctx.Args[k] = arg
+	}
+	return nil
+}
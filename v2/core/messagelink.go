@@ -0,0 +1,70 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// messagelink.go
+// This file contains the parser used by MessageLink args, which take a link to a Discord message
+
+// messageLinkRegex
+// Matches a message link on discord.com or the old discordapp.com, including the canary and ptb clients.
+// DMs use @me in place of the guild ID, and links can be wrapped in <> to suppress their embed.
+var messageLinkRegex = regexp.MustCompile(`^<?https?://(?:(?:canary|ptb)\.)?discord(?:app)?\.com/channels/([0-9]{17,20}|@me)/([0-9]{17,20})/([0-9]{17,20})/?>?$`)
+
+// ParseMessageLink
+// Parses a message link into the IDs of the guild, channel and message it points to
+// The guild ID is empty for a message in a DM.
+func ParseMessageLink(in string) (*discordgo.MessageReference, error) {
+	match := messageLinkRegex.FindStringSubmatch(strings.TrimSpace(in))
+	if match == nil {
+		return nil, fmt.Errorf("%q is not a link to a message", in)
+	}
+	ref := &discordgo.MessageReference{
+		GuildID:   match[1],
+		ChannelID: match[2],
+		MessageID: match[3],
+	}
+	if ref.GuildID == "@me" {
+		ref.GuildID = ""
+	}
+	return ref, nil
+}
+
+// validateMessageLink
+// The argValidators entry for MessageLink args, so commands can rely on MessageLinkValue.
+func validateMessageLink(value string, _ *ArgInfo) (string, error) {
+	if _, err := ParseMessageLink(value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// MessageLinkValue
+// Returns the IDs the arg's message link points to, see ParseMessageLink. Invalid links are nil.
+func (ag CommandArg) MessageLinkValue() *discordgo.MessageReference {
+	ref, _ := ParseMessageLink(ag.StringValue())
+	return ref
+}
+
+// LinkedMessage
+// Fetches the message the arg's message link points to.
+func (ag CommandArg) LinkedMessage() (*discordgo.Message, error) {
+	ref, err := ParseMessageLink(ag.StringValue())
+	if err != nil {
+		return nil, err
+	}
+	message, err := API.ChannelMessage(ref.ChannelID, ref.MessageID)
+	if err != nil {
+		return nil, err
+	}
+	// Messages fetched over REST don't include their guild
+	if message.GuildID == "" {
+		message.GuildID = ref.GuildID
+	}
+	return message, nil
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"time"
+)
+
+// mutes.go
+// This file contains the channel mutes, which stop the bot from responding in a channel for a while
+
+// MuteChannel
+// Stops the bot from responding to anyone but bot admins in a channel until the given time
+// The mute expires on its own, and muting a channel again replaces its expiry.
+func (g *Guild) MuteChannel(id string, until time.Time) {
+	g.infoLock.Lock()
+	if g.Info.MutedChannels == nil {
+		g.Info.MutedChannels = make(map[string]int64)
+	}
+	g.Info.MutedChannels[id] = until.Unix()
+	g.infoLock.Unlock()
+	g.save()
+}
+
+// UnmuteChannel
+// Lets the bot respond in a muted channel again, returning false if the channel wasn't muted.
+func (g *Guild) UnmuteChannel(id string) bool {
+	g.infoLock.Lock()
+	_, ok := g.Info.MutedChannels[id]
+	delete(g.Info.MutedChannels, id)
+	g.infoLock.Unlock()
+	if ok {
+		g.save()
+	}
+	return ok
+}
+
+// ChannelMutedUntil
+// Returns when the mute on a channel expires, and whether the channel is muted right now.
+func (g *Guild) ChannelMutedUntil(id string) (time.Time, bool) {
+	g.infoLock.RLock()
+	until, ok := g.Info.MutedChannels[id]
+	g.infoLock.RUnlock()
+	if !ok {
+		return time.Time{}, false
+	}
+	expiry := time.Unix(until, 0)
+	if time.Now().Before(expiry) {
+		return expiry, true
+	}
+	// The mute is over, so it is cleared out the next time the channel is checked
+	g.infoLock.Lock()
+	if g.Info.MutedChannels[id] == until {
+		delete(g.Info.MutedChannels, id)
+	}
+	g.infoLock.Unlock()
+	g.save()
+	return time.Time{}, false
+}
+
+// IsChannelMuted
+// Check if the bot has been muted in a channel, see MuteChannel.
+func (g *Guild) IsChannelMuted(id string) bool {
+	_, muted := g.ChannelMutedUntil(id)
+	return muted
+}
+
+// mutedFor
+// Check if the bot should stay quiet for a user in a channel. Bot admins are never muted.
+func mutedFor(g *Guild, channelID string, userID string) bool {
+	if g == nil || g.ID == "" || IsAdmin(userID) {
+		return false
+	}
+	return g.IsChannelMuted(channelID)
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// notfound.go
+// This file contains the debounce for command-not-found events, so a user repeating a bad trigger doesn't spam logs
+
+// notFoundWindow
+// How long after a command-not-found event further ones from the same user are ignored.
+var notFoundWindow = 3 * time.Second
+
+var (
+	notFoundSeen = make(map[string]time.Time)
+	notFoundLock sync.Mutex
+)
+
+// SetNotFoundDebounce
+// Sets how long repeated command-not-found events from the same user are ignored for
+// A window of 0 or less reports every event.
+func SetNotFoundDebounce(window time.Duration) {
+	notFoundLock.Lock()
+	notFoundWindow = window
+	notFoundLock.Unlock()
+}
+
+// debounceNotFound
+// Records a command-not-found event for a user, returning true if it should be ignored
+// because the user already had one within the window.
+func debounceNotFound(userID string) bool {
+	notFoundLock.Lock()
+	defer notFoundLock.Unlock()
+	if notFoundWindow <= 0 {
+		return false
+	}
+	now := time.Now()
+	if last, ok := notFoundSeen[userID]; ok && now.Sub(last) < notFoundWindow {
+		return true
+	}
+	notFoundSeen[userID] = now
+	// Drop stale entries so the map doesn't grow forever
+	for id, seen := range notFoundSeen {
+		if now.Sub(seen) >= notFoundWindow {
+			delete(notFoundSeen, id)
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// observers.go
+// This file lets other packages watch commands run, e.g. to export metrics, without the core depending on them
+
+// CommandRun
+// Describes a single run of a command's function, see AddCommandObserver.
+type CommandRun struct {
+	Trigger  string        // The command's trigger, prefixed with its parent's for child commands
+	GuildID  string        // The guild the command was run in, empty in DMs
+	Slash    bool          // If the command was run as a slash command
+	Duration time.Duration // How long the command's function took
+	Panicked bool          // If the command's function panicked
+}
+
+// CommandObserver
+// A function that is told about every command run.
+type CommandObserver func(run CommandRun)
+
+var (
+	observers     []CommandObserver
+	observersLock sync.RWMutex
+)
+
+// AddCommandObserver
+// Adds a function that is called after every command function returns or panics
+// Observers are called on the command's goroutine, so they should be quick.
+func AddCommandObserver(observer CommandObserver) {
+	observersLock.Lock()
+	defer observersLock.Unlock()
+	observers = append(observers, observer)
+}
+
+// observeCommand
+// Runs the command's function, then tells the observers about it. Panics are passed on after the observers are told,
+// so they are still reported as usual.
+func observeCommand(command Command, ctx *CmdContext) {
+	observersLock.RLock()
+	watching := len(observers) > 0
+	observersLock.RUnlock()
+	if !watching {
+		command.Function(ctx)
+		return
+	}
+	start := time.Now()
+	defer func() {
+		r := recover()
+		run := CommandRun{
+			Trigger:  commandKey(command.Info),
+			GuildID:  ctx.GuildID(),
+			Slash:    ctx.Interaction != nil,
+			Duration: time.Since(start),
+			Panicked: r != nil,
+		}
+		observersLock.RLock()
+		for _, observer := range observers {
+			observer(run)
+		}
+		observersLock.RUnlock()
+		if r != nil {
+			panic(r)
+		}
+	}()
+	command.Function(ctx)
+}
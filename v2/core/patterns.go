@@ -0,0 +1,200 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// patterns.go
+// This file contains pattern commands, which run when a whole message matches a regex instead of when it starts with the prefix
+
+// maxPatternProgram
+// The most instructions a pattern can compile to, so one pattern can't make every message slow to check.
+const maxPatternProgram = 1000
+
+// PatternCommand
+// A handler that runs on any message matching its pattern. Pattern commands are off until a guild enables them.
+type PatternCommand struct {
+	Pattern  *regexp.Regexp
+	Function BotFunction
+}
+
+var (
+	patternCommands     []PatternCommand
+	patternCommandsLock sync.RWMutex
+)
+
+// AddPatternCommand
+// Registers a handler that runs when a message with no matching prefix command matches the pattern.
+// The pattern is identified by its source, which is what guilds use to enable it, see Guild.EnablePatternCommand.
+// Patterns that nest unbounded repeats, or compile to a very large program, are rejected.
+func AddPatternCommand(pattern *regexp.Regexp, fn BotFunction) {
+	if pattern == nil || fn == nil {
+		Log.Errorf("Not registering a pattern command without a pattern or function")
+		return
+	}
+	if err := checkPattern(pattern); err != nil {
+		Log.Errorf("Not registering pattern command %q: %s", pattern.String(), err)
+		return
+	}
+	patternCommandsLock.Lock()
+	defer patternCommandsLock.Unlock()
+	for _, command := range patternCommands {
+		if command.Pattern.String() == pattern.String() {
+			Log.Errorf("Pattern command %q was already registered", pattern.String())
+			return
+		}
+	}
+	patternCommands = append(patternCommands, PatternCommand{Pattern: pattern, Function: fn})
+}
+
+// PatternCommands
+// Returns the sources of all the registered pattern commands.
+func PatternCommands() []string {
+	patternCommandsLock.RLock()
+	defer patternCommandsLock.RUnlock()
+	patterns := make([]string, 0, len(patternCommands))
+	for _, command := range patternCommands {
+		patterns = append(patterns, command.Pattern.String())
+	}
+	return patterns
+}
+
+// checkPattern
+// Go's regexp never backtracks, so matching is always linear, but a nested repeat like (a+)+ is still rejected,
+// since it's almost always a mistake and is catastrophic anywhere the pattern gets reused.
+func checkPattern(pattern *regexp.Regexp) error {
+	re, err := syntax.Parse(pattern.String(), syntax.Perl)
+	if err != nil {
+		return err
+	}
+	if nestedRepeat(re, false) {
+		return errors.New("the pattern repeats something that already repeats")
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return err
+	}
+	if len(prog.Inst) > maxPatternProgram {
+		return fmt.Errorf("the pattern is too complex, it compiles to more than %d instructions", maxPatternProgram)
+	}
+	return nil
+}
+
+// nestedRepeat
+// Check if an unbounded repeat appears inside another repeat.
+func nestedRepeat(re *syntax.Regexp, inRepeat bool) bool {
+	unbounded := re.Op == syntax.OpStar || re.Op == syntax.OpPlus || (re.Op == syntax.OpRepeat && re.Max == -1)
+	if unbounded && inRepeat {
+		return true
+	}
+	repeat := inRepeat || unbounded || re.Op == syntax.OpRepeat
+	for _, sub := range re.Sub {
+		if nestedRepeat(sub, repeat) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPatternCommand
+// Check if a pattern command with this source is registered.
+func isPatternCommand(pattern string) bool {
+	patternCommandsLock.RLock()
+	defer patternCommandsLock.RUnlock()
+	for _, command := range patternCommands {
+		if command.Pattern.String() == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// EnablePatternCommand
+// Turns on a registered pattern command in this guild.
+func (g *Guild) EnablePatternCommand(pattern string) error {
+	if !isPatternCommand(pattern) {
+		return fmt.Errorf("%q is not a pattern command", pattern)
+	}
+	g.infoLock.Lock()
+	for _, enabled := range g.Info.EnabledPatterns {
+		if enabled == pattern {
+			g.infoLock.Unlock()
+			return nil
+		}
+	}
+	g.Info.EnabledPatterns = append(g.Info.EnabledPatterns, pattern)
+	g.infoLock.Unlock()
+	g.save()
+	return nil
+}
+
+// DisablePatternCommand
+// Turns off a pattern command in this guild.
+func (g *Guild) DisablePatternCommand(pattern string) {
+	g.infoLock.Lock()
+	for i, enabled := range g.Info.EnabledPatterns {
+		if enabled == pattern {
+			g.Info.EnabledPatterns = append(g.Info.EnabledPatterns[:i:i], g.Info.EnabledPatterns[i+1:]...)
+			break
+		}
+	}
+	g.infoLock.Unlock()
+	g.save()
+}
+
+// PatternCommandEnabled
+// Check if a pattern command is turned on in this guild.
+func (g *Guild) PatternCommandEnabled(pattern string) bool {
+	g.infoLock.RLock()
+	defer g.infoLock.RUnlock()
+	for _, enabled := range g.Info.EnabledPatterns {
+		if enabled == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// runPatternCommand
+// Runs the first pattern command enabled in the guild that matches the message, and reports if one ran.
+// Pattern commands run through runCommand like any other, as a command whose trigger is the pattern's source,
+// so the kill switch and observers see them under that trigger.
+func runPatternCommand(g *Guild, message *discordgo.Message, channel *discordgo.Channel) bool {
+	// Auto-responders just stay quiet during maintenance, instead of answering every matching message
+	if g.ID == "" || message.Author.Bot || inMaintenanceFor(message.Author.ID) {
+		return false
+	}
+	patternCommandsLock.RLock()
+	var match *PatternCommand
+	for i, command := range patternCommands {
+		if g.PatternCommandEnabled(command.Pattern.String()) && command.Pattern.MatchString(message.Content) {
+			match = &patternCommands[i]
+			break
+		}
+	}
+	patternCommandsLock.RUnlock()
+	if match == nil {
+		return false
+	}
+	command := Command{Info: CommandInfo{Trigger: match.Pattern.String()}, Function: match.Function}
+	// Turned off auto-responders stay quiet too, instead of sending the guild's blocked command message
+	if isDisabledFor(command.Info, message.Author.ID) {
+		return false
+	}
+	defer handleCommandError(command.Info.Trigger, g.ID, channel.ID, message.Author.ID)
+	runCommand(command, &CmdContext{
+		Guild:     g,
+		Cmd:       command.Info,
+		Args:      Arguments{},
+		Message:   message,
+		ArgString: message.Content,
+		channel:   channel,
+	})
+	return true
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// presence.go
+// This file contains the bot's custom presence, which is saved so it is shown again after a reconnect
+
+// Presence
+// The status and activity the bot shows, see SetPresence.
+type Presence struct {
+	Status       string
+	Activity     string
+	ActivityType discordgo.ActivityType
+}
+
+// presenceStatuses
+// The statuses a bot can show.
+var presenceStatuses = map[string]discordgo.Status{
+	"online":    discordgo.StatusOnline,
+	"idle":      discordgo.StatusIdle,
+	"dnd":       discordgo.StatusDoNotDisturb,
+	"invisible": discordgo.StatusInvisible,
+}
+
+// activityPrefixes
+// The words an activity can start with to pick how it is shown, e.g: "watching the logs". Anything else is a game.
+var activityPrefixes = []struct {
+	prefix       string
+	activityType discordgo.ActivityType
+}{
+	{"playing ", discordgo.ActivityTypeGame},
+	{"watching ", discordgo.ActivityTypeWatching},
+	{"listening to ", discordgo.ActivityTypeListening},
+	{"competing in ", discordgo.ActivityTypeCompeting},
+}
+
+// customPresence
+// The presence set with SetPresence, or nil if the default presence is shown.
+var customPresence *Presence
+
+// presenceLock
+// Guards customPresence, since it is changed by a command and read on every ready event.
+var presenceLock sync.Mutex
+
+// defaultPresence
+// Shows the presence used when none has been set, see SetDefaultPresence.
+var defaultPresence func()
+
+// SetDefaultPresence
+// Sets the function that shows the bot's default presence, so ResetPresence can go back to it.
+func SetDefaultPresence(fn func()) {
+	defaultPresence = fn
+}
+
+// SetPresence
+// Sets the bot's status (online, idle, dnd or invisible) and activity, which shows as "Playing" unless it
+// starts with "watching", "listening to" or "competing in". An empty activity shows only the status.
+// The presence is saved with the guild provider and shown again whenever the bot reconnects.
+func SetPresence(status string, activity string) error {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if _, ok := presenceStatuses[status]; !ok {
+		return fmt.Errorf("%q is not a status, use online, idle, dnd or invisible", status)
+	}
+	presence := Presence{Status: status, Activity: strings.TrimSpace(activity)}
+	lower := strings.ToLower(presence.Activity)
+	for _, p := range activityPrefixes {
+		if strings.HasPrefix(lower, p.prefix) {
+			presence.Activity = strings.TrimSpace(presence.Activity[len(p.prefix):])
+			presence.ActivityType = p.activityType
+			break
+		}
+	}
+	presenceLock.Lock()
+	customPresence = &presence
+	if currentProvider.SavePresence != nil {
+		currentProvider.SavePresence(customPresence)
+	}
+	presenceLock.Unlock()
+	return updatePresence(presence)
+}
+
+// ResetPresence
+// Forgets the presence set with SetPresence, and shows the default presence again.
+func ResetPresence() {
+	presenceLock.Lock()
+	customPresence = nil
+	if currentProvider.SavePresence != nil {
+		currentProvider.SavePresence(nil)
+	}
+	presenceLock.Unlock()
+	if defaultPresence != nil {
+		defaultPresence()
+	}
+}
+
+// ApplyPresence
+// Shows the presence set with SetPresence again, returning false if there isn't one, so the default can be shown instead.
+func ApplyPresence() bool {
+	presenceLock.Lock()
+	presence := customPresence
+	presenceLock.Unlock()
+	if presence == nil {
+		return false
+	}
+	if err := updatePresence(*presence); err != nil {
+		Log.Errorf("Unable to update presence: %s", err)
+	}
+	return true
+}
+
+// updatePresence
+// Sends a presence to Discord.
+func updatePresence(presence Presence) error {
+	data := discordgo.UpdateStatusData{Status: string(presenceStatuses[presence.Status])}
+	if presence.Activity != "" {
+		data.Activities = []*discordgo.Activity{{Name: presence.Activity, Type: presence.ActivityType}}
+	}
+	return Session.UpdateStatusComplex(data)
+}
+
+// loadPresence
+// Reloads the presence that was set before the bot last stopped.
+func loadPresence() {
+	if currentProvider.LoadPresence == nil {
+		return
+	}
+	presence := currentProvider.LoadPresence()
+	presenceLock.Lock()
+	customPresence = presence
+	presenceLock.Unlock()
+}
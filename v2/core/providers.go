@@ -1,6 +1,15 @@
 package core
 
+// GuildProvider
+// The functions a storage backend provides to save and load guild data.
 type GuildProvider struct {
-	Save func(guild *Guild)
-	Load func() map[string]*Guild
+	Save         func(guild *Guild)
+	Load         func() map[string]*Guild
+	LoadGuild    func(guildID string) (GuildInfo, error) // Loads a single guild's stored info
+	SaveJobs     func(jobs []ScheduledJob)               // Saves the pending scheduled jobs
+	LoadJobs     func() []ScheduledJob                   // Loads the scheduled jobs that were pending when the bot stopped
+	SaveRemaps   func(remaps []TriggerRemap)             // Saves the trigger remaps, see RemapTrigger
+	LoadRemaps   func() []TriggerRemap                   // Loads the trigger remaps, in the order they were made
+	SavePresence func(presence *Presence)                // Saves the presence set with SetPresence, nil if it was reset
+	LoadPresence func() *Presence                        // Loads the saved presence, nil if there isn't one
 }
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// reactions.go
+// This file contains helpers for waiting on reactions, for confirmations and paginators
+
+// ErrReactionTimeout
+// Returned by AwaitReaction when nobody reacted in time.
+var ErrReactionTimeout = errors.New("timed out waiting for a reaction")
+
+// reactionSpacing
+// How long AddReactions waits between reactions, since Discord only allows about one reaction on a message every 250ms.
+var reactionSpacing = 250 * time.Millisecond
+
+// AddReactions
+// Adds reactions to a message one at a time, spaced out so the paginator's controls don't hit the rate limit,
+// and retrying with backoff if they do anyway. Emojis can be in any form ParseEmoji accepts.
+// Stops early if the message was deleted, returning the error that said so.
+func AddReactions(channelID, messageID string, emojis []string) error {
+	for i, emoji := range emojis {
+		if parsed, ok := ParseEmoji(emoji); ok {
+			emoji = parsed
+		}
+		if i > 0 {
+			time.Sleep(reactionSpacing)
+		}
+		err := sendWithRetry(func() error {
+			return API.MessageReactionAdd(channelID, messageID, emoji)
+		})
+		if isInvalidReference(err) {
+			return err
+		}
+		if err != nil {
+			Log.Errorf("Failed to add reaction %s to message %s: %s", emoji, messageID, err)
+		}
+	}
+	return nil
+}
+
+// AwaitReaction
+// Waits for a user to react to a message with one of the given emojis, and returns the emoji they picked, as it was given.
+// Emojis can be unicode or custom emoji, in any form ParseEmoji accepts. With no emojis any reaction is accepted,
+// and returned as name:id for custom emoji. An empty userID accepts a reaction from anyone but the bot.
+// The listener is removed once this returns, whether or not there was a reaction. Adding the emojis to
+// the message is left to the caller.
+func AwaitReaction(messageID, channelID, userID string, emojis []string, timeout time.Duration) (string, error) {
+	// Reactions arrive as name:id, so the emojis are kept by that name
+	wanted := make(map[string]string, len(emojis))
+	for _, emoji := range emojis {
+		name := emoji
+		if parsed, ok := ParseEmoji(emoji); ok {
+			name = parsed
+		}
+		wanted[name] = emoji
+	}
+	result := make(chan string, 1)
+	remove := Session.AddHandler(func(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+		if r.MessageID != messageID || r.ChannelID != channelID {
+			return
+		}
+		if (userID != "" && r.UserID != userID) || (userID == "" && r.UserID == s.State.User.ID) {
+			return
+		}
+		emoji, ok := wanted[r.Emoji.APIName()]
+		if len(wanted) == 0 {
+			emoji, ok = r.Emoji.APIName(), true
+		}
+		if !ok {
+			return
+		}
+		// Only the first reaction counts, the rest are dropped instead of blocking the handler
+		select {
+		case result <- emoji:
+		default:
+		}
+	})
+	defer remove()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case emoji := <-result:
+		return emoji, nil
+	case <-timer.C:
+		return "", ErrReactionTimeout
+	}
+}
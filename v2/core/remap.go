@@ -0,0 +1,210 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// remap.go
+// This file contains trigger remapping, for renaming a command without breaking anyone still using its old name
+
+// TriggerRemap
+// A command's trigger being changed from Old to New. Old is kept as an alias.
+type TriggerRemap struct {
+	Old string
+	New string
+}
+
+// triggerRemaps
+// The saved remaps, in order. Remaps made since the bot started are only applied after the next restart.
+var triggerRemaps []TriggerRemap
+
+// remapLock
+// Guards triggerRemaps.
+var remapLock sync.Mutex
+
+// RemapTrigger
+// Changes a command's trigger to newTrigger, keeping the old trigger as an alias
+// The remap is saved with the guild provider, and takes effect the next time the bot starts, when slash commands are
+// registered under the new trigger. The command maps are read without locks while messages are handled, so they are
+// only ever changed at startup, before the handlers are added. Nothing changes until then.
+func RemapTrigger(oldTrigger string, newTrigger string) error {
+	remapLock.Lock()
+	defer remapLock.Unlock()
+	oldKey, newKey, err := checkRemap(oldTrigger, newTrigger)
+	if err != nil {
+		return err
+	}
+	for _, remap := range triggerRemaps {
+		if remap.Old == oldKey || remap.New == newKey {
+			return fmt.Errorf("%s was already remapped, restart the bot before remapping it again", remap.Old)
+		}
+	}
+	triggerRemaps = append(triggerRemaps, TriggerRemap{Old: oldKey, New: newKey})
+	if currentProvider.SaveRemaps != nil {
+		currentProvider.SaveRemaps(triggerRemaps)
+	}
+	return nil
+}
+
+// TriggerRemaps
+// Returns every saved remap, in the order they were made.
+func TriggerRemaps() []TriggerRemap {
+	remapLock.Lock()
+	defer remapLock.Unlock()
+	return append([]TriggerRemap(nil), triggerRemaps...)
+}
+
+// checkRemap
+// Check if a command can be moved to a new trigger, returning the keys it would move between.
+func checkRemap(oldTrigger string, newTrigger string) (string, string, error) {
+	oldKey := strings.ToLower(strings.TrimSpace(oldTrigger))
+	if target, ok := commandAliases[oldKey]; ok {
+		oldKey = strings.ToLower(target)
+	}
+	newKey := strings.ToLower(strings.TrimSpace(newTrigger))
+	if _, ok := commands[oldKey]; !ok {
+		return "", "", fmt.Errorf("command %s not found", oldTrigger)
+	}
+	if newKey == "" || len(strings.Fields(newKey)) > 1 {
+		return "", "", fmt.Errorf("%q is not a valid trigger", newTrigger)
+	}
+	if target, ok := commandAliases[newKey]; ok && strings.ToLower(target) != oldKey {
+		return "", "", fmt.Errorf("%s is already used by command %s", newKey, target)
+	}
+	if _, ok := slashGroups[newKey]; ok {
+		return "", "", fmt.Errorf("%s is already used by a slash group", newKey)
+	}
+	if newKey == oldKey {
+		return "", "", fmt.Errorf("command %s already has that trigger", oldKey)
+	}
+	return oldKey, newKey, nil
+}
+
+// applyRemap
+// Moves a command to a new trigger. This changes the command maps, so it must only run before the handlers are added.
+func applyRemap(oldTrigger string, newTrigger string) (TriggerRemap, error) {
+	oldKey, newKey, err := checkRemap(oldTrigger, newTrigger)
+	if err != nil {
+		return TriggerRemap{}, err
+	}
+	command := commands[oldKey]
+
+	command.Info.Trigger = newKey
+	command.Info.Aliases = append(append([]string(nil), command.Info.Aliases...), newKey)
+
+	newCommands := make(map[string]Command, len(commands))
+	for key, c := range commands {
+		if key != oldKey {
+			newCommands[key] = c
+		}
+	}
+	newCommands[newKey] = command
+
+	newAliases := make(map[string]string, len(commandAliases)+1)
+	for alias, target := range commandAliases {
+		if strings.ToLower(target) == oldKey {
+			target = newKey
+		}
+		newAliases[alias] = target
+	}
+	newAliases[newKey] = newKey
+
+	newChildren := make(ChildCommand, len(childCommands))
+	for parent, children := range childCommands {
+		if parent != oldKey {
+			newChildren[parent] = children
+			continue
+		}
+		moved := make(map[string]Command, len(children))
+		for trigger, child := range children {
+			child.Info.ParentID = newKey
+			moved[trigger] = child
+		}
+		newChildren[newKey] = moved
+	}
+
+	newSlash := make(map[string]discordgo.ApplicationCommand, len(slashCommands))
+	_, wasSlash := slashCommands[oldKey]
+	for key, s := range slashCommands {
+		if key != oldKey {
+			newSlash[key] = s
+		}
+	}
+
+	for name, group := range slashGroups {
+		if !inSlashGroup(name, oldKey) {
+			continue
+		}
+		triggers := make([]string, len(group.Triggers))
+		for i, trigger := range group.Triggers {
+			if trigger == oldKey {
+				trigger = newKey
+			}
+			triggers[i] = trigger
+		}
+		group.Triggers = triggers
+		slashGroups[name] = group
+	}
+
+	moveCommandKeys(oldKey, newKey)
+	commands, commandAliases, childCommands, slashCommands = newCommands, newAliases, newChildren, newSlash
+	if wasSlash {
+		info := command.Info
+		AddSlashCommand(&info)
+	}
+	return TriggerRemap{Old: oldKey, New: newKey}, nil
+}
+
+// moveCommandKeys
+// Moves the concurrency limit and kill switch state kept under a command's old key, and its children's, to the new key.
+func moveCommandKeys(oldKey string, newKey string) {
+	moveKey := func(key string) string {
+		if key == oldKey {
+			return newKey
+		}
+		if strings.HasPrefix(key, oldKey+" ") {
+			return newKey + strings.TrimPrefix(key, oldKey)
+		}
+		return key
+	}
+	semaphores := make(map[string]chan struct{}, len(commandSemaphores))
+	for key, semaphore := range commandSemaphores {
+		semaphores[moveKey(key)] = semaphore
+	}
+	commandSemaphores = semaphores
+
+	disabledCommandsLock.Lock()
+	for key := range disabledCommands {
+		if moved := moveKey(key); moved != key {
+			delete(disabledCommands, key)
+			disabledCommands[moved] = true
+		}
+	}
+	disabledCommandsLock.Unlock()
+}
+
+// loadTriggerRemaps
+// Applies the remaps that were saved before the bot last stopped, in the order they were made.
+// Without a provider to load them from, the remaps made before the bot started are applied instead.
+// Remaps for commands that no longer exist are dropped.
+func loadTriggerRemaps() {
+	remapLock.Lock()
+	defer remapLock.Unlock()
+	saved := triggerRemaps
+	if currentProvider.LoadRemaps != nil {
+		saved = currentProvider.LoadRemaps()
+	}
+	triggerRemaps = nil
+	for _, remap := range saved {
+		applied, err := applyRemap(remap.Old, remap.New)
+		if err != nil {
+			Log.Warningf("Dropping remap of %s to %s: %s", remap.Old, remap.New, err)
+			continue
+		}
+		triggerRemaps = append(triggerRemaps, applied)
+	}
+}
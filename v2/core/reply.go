@@ -0,0 +1,340 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// reply.go
+// This file contains helpers for replying to a command with plain content, files, or embeds
+
+// maxMessageLength
+// The most characters Discord allows in a single message.
+const maxMessageLength = 2000
+
+// maxReplyChunks
+// The most messages ReplyLong splits content into. Anything longer is uploaded as a file instead.
+const maxReplyChunks = 5
+
+// maxFileSize
+// The largest file Discord lets a bot upload in a guild without boosts.
+const maxFileSize = 10 << 20
+
+// maxEmbeds
+// The most embeds Discord allows in a single message.
+const maxEmbeds = 10
+
+// ErrTooManyEmbeds
+// Returned by ReplyEmbeds when more embeds are given than fit in a message.
+var ErrTooManyEmbeds = errors.New("too many embeds for one message")
+
+// ErrDeferPending
+// Returned by Followup when an ephemeral followup is sent before a deferred interaction's message was replaced.
+// Discord would use that followup to replace the deferred message, with the defer's visibility instead.
+var ErrDeferPending = errors.New("the deferred response must be sent before an ephemeral followup")
+
+// ErrFileTooLarge
+// Returned by ReplyFile when the file is over the upload limit.
+var ErrFileTooLarge = errors.New("file is too large to upload")
+
+// codeFence
+// The marker that opens and closes a code block.
+const codeFence = "```"
+
+// Reply
+// Replies with content. On the message path the reply references the invoking message, unless it was deleted.
+func (ctx *CmdContext) Reply(content string) error {
+	return ctx.sendReply(&discordgo.MessageSend{Content: content}, true)
+}
+
+// ReplyPlain
+// Responds with content, posted in the channel without referencing the invoking message.
+// Slash commands are answered as usual, since interactions have no message to reference.
+func (ctx *CmdContext) ReplyPlain(content string) error {
+	return ctx.send(&discordgo.MessageSend{Content: content}, true, true)
+}
+
+// ReplyLong
+// Replies with content of any length. Content over the message limit is split on line boundaries into
+// several messages, closing and reopening any code block that is split. Content that would take more
+// than maxReplyChunks messages is uploaded as a text file instead.
+func (ctx *CmdContext) ReplyLong(content string) error {
+	chunks := chunkContent(content, maxMessageLength)
+	if len(chunks) > maxReplyChunks {
+		return ctx.sendReply(&discordgo.MessageSend{
+			Content: ctx.Translate(MsgResponseAsFile),
+			Files: []*discordgo.File{{
+				Name:        "response.txt",
+				ContentType: "text/plain",
+				Reader:      strings.NewReader(content),
+			}},
+		}, true)
+	}
+	for i, chunk := range chunks {
+		if err := ctx.sendReply(&discordgo.MessageSend{Content: chunk}, i == 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplyFile
+// Replies with a file read from r. For interactions that were already answered or deferred, the file is sent as a followup.
+// Files over the upload limit aren't sent, and an error wrapping ErrFileTooLarge is returned instead.
+func (ctx *CmdContext) ReplyFile(name string, r io.Reader) error {
+	// Read one byte past the limit, so a file that is too large is caught without reading all of it
+	data, err := io.ReadAll(io.LimitReader(r, maxFileSize+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxFileSize {
+		return fmt.Errorf("%w: %s is over the %d MiB limit", ErrFileTooLarge, name, maxFileSize>>20)
+	}
+	return ctx.sendReply(&discordgo.MessageSend{
+		Files: []*discordgo.File{{
+			Name:   name,
+			Reader: bytes.NewReader(data),
+		}},
+	}, true)
+}
+
+// ReplyEmbed
+// Replies with a single embed.
+func (ctx *CmdContext) ReplyEmbed(embed *discordgo.MessageEmbed) error {
+	return ctx.ReplyEmbeds(embed)
+}
+
+// ReplyEmbeds
+// Replies with several embeds in one message. Discord allows up to 10 embeds per message,
+// so passing more returns an error wrapping ErrTooManyEmbeds without sending anything.
+func (ctx *CmdContext) ReplyEmbeds(embeds ...*discordgo.MessageEmbed) error {
+	if len(embeds) == 0 {
+		return errors.New("no embeds to reply with")
+	}
+	if len(embeds) > maxEmbeds {
+		return fmt.Errorf("%w: %d were given, but the limit is %d", ErrTooManyEmbeds, len(embeds), maxEmbeds)
+	}
+	return ctx.sendReply(&discordgo.MessageSend{Embeds: embeds}, true)
+}
+
+// Followup
+// Sends content as a followup to a slash command, seen only by the user who ran it if ephemeral is set, whatever
+// the visibility of the command's first response. This way a public result can come with a private warning.
+// If the interaction wasn't answered yet, the followup answers it. Message commands reply as usual, since
+// their replies can't be hidden.
+func (ctx *CmdContext) Followup(content string, ephemeral bool) error {
+	if ctx.Interaction == nil {
+		return ctx.sendReply(&discordgo.MessageSend{Content: content}, false)
+	}
+	if ephemeral && ctx.deferPending {
+		return ErrDeferPending
+	}
+	var flags discordgo.MessageFlags
+	if ephemeral {
+		flags = discordgo.MessageFlagsEphemeral
+	}
+	mentions := ctx.allowedMentions()
+	if !ctx.acknowledged {
+		err := sendWithRetry(func() error {
+			return API.InteractionRespond(ctx.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content:         content,
+					Flags:           flags,
+					AllowedMentions: mentions,
+				},
+			})
+		})
+		// The interaction was already answered, so this is a followup after all
+		if err == nil {
+			if !ephemeral {
+				ctx.expireInteractionResponse()
+			}
+			return nil
+		}
+	}
+	err := sendWithRetry(func() error {
+		message, err := API.FollowupMessageCreate(ctx.Interaction, true, &discordgo.WebhookParams{
+			Content:         content,
+			Flags:           flags,
+			AllowedMentions: mentions,
+		})
+		ctx.expireResponse(message)
+		return err
+	})
+	// A followup to a deferred interaction replaces the deferred message
+	if err == nil {
+		ctx.deferPending = false
+	}
+	return err
+}
+
+// sendReply
+// Sends a message in response to the command. The first message of a reply answers the interaction,
+// or replies to the invoking message; any after that are sent as followups or plain channel messages.
+func (ctx *CmdContext) sendReply(data *discordgo.MessageSend, first bool) error {
+	return ctx.send(data, first, false)
+}
+
+// send
+// Sends a message in response to the command, see sendReply. Plain messages on the message path are posted
+// in the channel without replying to the invoking message.
+func (ctx *CmdContext) send(data *discordgo.MessageSend, first bool, plain bool) error {
+	data.AllowedMentions = ctx.allowedMentions()
+	files, err := bufferFiles(data.Files)
+	if err != nil {
+		return err
+	}
+	if ctx.Interaction != nil {
+		// The first message of a reply to a deferred interaction replaces the deferred message
+		if first && ctx.acknowledged {
+			content := data.Content
+			edit := &discordgo.WebhookEdit{
+				Content:         &content,
+				Files:           files(),
+				AllowedMentions: data.AllowedMentions,
+			}
+			if len(data.Embeds) > 0 {
+				edit.Embeds = &data.Embeds
+			}
+			message, err := EditInteractionResponse(ctx, edit)
+			ctx.expireResponse(message)
+			return err
+		}
+		if first {
+			err := sendWithRetry(func() error {
+				return API.InteractionRespond(ctx.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content:         data.Content,
+						Embeds:          data.Embeds,
+						Files:           files(),
+						AllowedMentions: data.AllowedMentions,
+					},
+				})
+			})
+			// If the interaction was already acknowledged (e.g: deferred), fall through to a followup
+			if err == nil {
+				ctx.expireInteractionResponse()
+				return nil
+			}
+			Log.Debugf("unable to respond to interaction %s, sending a followup instead: %s", ctx.Interaction.ID, err)
+		}
+		return sendWithRetry(func() error {
+			message, err := API.FollowupMessageCreate(ctx.Interaction, true, &discordgo.WebhookParams{
+				Content:         data.Content,
+				Embeds:          data.Embeds,
+				Files:           files(),
+				AllowedMentions: data.AllowedMentions,
+			})
+			ctx.expireResponse(message)
+			return err
+		})
+	}
+	data.Files = files()
+	var message *discordgo.Message
+	if first && !plain {
+		message, err = ReplyToMessage(ctx.Message, data)
+	} else {
+		message, err = ReplyToUser(ctx.Message.ChannelID, data)
+	}
+	ctx.expireResponse(message)
+	return err
+}
+
+// bufferFiles
+// Reads files into memory, returning a function that gives fresh copies of them. This way a send that is
+// retried, or falls back to a followup, doesn't upload files that were already read.
+func bufferFiles(files []*discordgo.File) (func() []*discordgo.File, error) {
+	contents := make([][]byte, len(files))
+	for i, file := range files {
+		content, err := io.ReadAll(file.Reader)
+		if err != nil {
+			return nil, err
+		}
+		contents[i] = content
+	}
+	return func() []*discordgo.File {
+		if len(files) == 0 {
+			return nil
+		}
+		copies := make([]*discordgo.File, len(files))
+		for i, file := range files {
+			copies[i] = &discordgo.File{
+				Name:        file.Name,
+				ContentType: file.ContentType,
+				Reader:      bytes.NewReader(contents[i]),
+			}
+		}
+		return copies
+	}, nil
+}
+
+// chunkContent
+// Splits content into chunks no longer than limit, preferring to split on newlines
+// If a code block is split, it is closed at the end of one chunk and reopened at the start of the next.
+func chunkContent(content string, limit int) []string {
+	if len(content) <= limit {
+		return []string{content}
+	}
+	var chunks []string
+	var current strings.Builder
+	// The line that opened the code block the current line is in, if any
+	fence := ""
+	for _, line := range splitLongLines(strings.Split(content, "\n"), limit/2) {
+		// Leave room to close a code block that is open at the end of the chunk
+		closing := 0
+		if fence != "" || strings.Count(line, codeFence)%2 == 1 {
+			closing = len("\n" + codeFence)
+		}
+		if current.Len() > 0 && current.Len()+len("\n")+len(line)+closing > limit {
+			chunk := current.String()
+			current.Reset()
+			if fence != "" {
+				chunk += "\n" + codeFence
+				current.WriteString(fence)
+			}
+			chunks = append(chunks, chunk)
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n")
+		}
+		current.WriteString(line)
+		// An odd number of fences on a line opens or closes a code block
+		if strings.Count(line, codeFence)%2 == 1 {
+			if fence == "" {
+				fence = codeFence + strings.TrimSpace(line[strings.LastIndex(line, codeFence)+len(codeFence):])
+			} else {
+				fence = ""
+			}
+		}
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// splitLongLines
+// Splits any line longer than limit bytes into several lines, without splitting a character.
+func splitLongLines(lines []string, limit int) []string {
+	var out []string
+	for _, line := range lines {
+		for len(line) > limit {
+			cut := limit
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			out = append(out, line[:cut])
+			line = line[cut:]
+		}
+		out = append(out, line)
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// resolve.go
+// This file contains cached user and member lookups, so commands that look up the same people over and over
+// (ban lists, warn history) don't make a request to Discord every time
+
+// resolveCacheTTL
+// How long a looked up user or member is kept, see SetResolveCache.
+var resolveCacheTTL = 5 * time.Minute
+
+// resolveCacheSize
+// The most users, and the most members, kept at once, see SetResolveCache.
+var resolveCacheSize = 1000
+
+// lookupCache
+// Looked up users or members, keyed by ID (or guild ID and user ID for members), and when each one expires.
+type lookupCache struct {
+	sync.Mutex
+	entries map[string]lookupEntry
+}
+
+// lookupEntry
+// A cached lookup, and when it expires.
+type lookupEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// resolveCacheLock
+// Guards resolveCacheTTL and resolveCacheSize.
+var resolveCacheLock sync.Mutex
+
+var (
+	userCache   = lookupCache{entries: make(map[string]lookupEntry)}
+	memberCache = lookupCache{entries: make(map[string]lookupEntry)}
+)
+
+// SetResolveCache
+// Sets how long ResolveUser and ResolveMember keep what they look up, and how many users and members they keep.
+// A ttl or size of zero or less turns the cache off. Anything already cached is dropped.
+func SetResolveCache(ttl time.Duration, size int) {
+	for _, cache := range []*lookupCache{&userCache, &memberCache} {
+		cache.Lock()
+		cache.entries = make(map[string]lookupEntry)
+		cache.Unlock()
+	}
+	resolveCacheLock.Lock()
+	resolveCacheTTL, resolveCacheSize = ttl, size
+	resolveCacheLock.Unlock()
+}
+
+// cacheLimits
+// Returns the cache's TTL and size.
+func cacheLimits() (time.Duration, int) {
+	resolveCacheLock.Lock()
+	defer resolveCacheLock.Unlock()
+	return resolveCacheTTL, resolveCacheSize
+}
+
+// get
+// Returns a cached value, if it hasn't expired.
+func (c *lookupCache) get(key string) (interface{}, bool) {
+	c.Lock()
+	defer c.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// put
+// Caches a value. If the cache is full, expired values are dropped first, then the value closest to expiring.
+func (c *lookupCache) put(key string, value interface{}, ttl time.Duration, size int) {
+	if ttl <= 0 || size <= 0 {
+		return
+	}
+	now := time.Now()
+	c.Lock()
+	defer c.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= size {
+		oldest := ""
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+				continue
+			}
+			if oldest == "" || entry.expires.Before(c.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		if len(c.entries) >= size {
+			delete(c.entries, oldest)
+		}
+	}
+	c.entries[key] = lookupEntry{value: value, expires: now.Add(ttl)}
+}
+
+// ResolveUser
+// Looks up a user by ID or mention, keeping the user for a while so repeated lookups don't go to Discord.
+func ResolveUser(id string) (*discordgo.User, error) {
+	id = CleanID(id)
+	if id == "" {
+		return nil, errors.New("provided ID is invalid")
+	}
+	if cached, ok := userCache.get(id); ok {
+		return cached.(*discordgo.User), nil
+	}
+	user, err := API.User(id)
+	if err != nil {
+		return nil, err
+	}
+	ttl, size := cacheLimits()
+	userCache.put(id, user, ttl, size)
+	return user, nil
+}
+
+// ResolveMember
+// Looks up a guild member by user ID or mention. Members in the state are used as they are, and any
+// looked up from Discord are kept for a while so repeated lookups don't go to Discord.
+func ResolveMember(guildID string, id string) (*discordgo.Member, error) {
+	id = CleanID(id)
+	if id == "" {
+		return nil, errors.New("provided ID is invalid")
+	}
+	if Session != nil && Session.State != nil {
+		if member, err := Session.State.Member(guildID, id); err == nil {
+			return member, nil
+		}
+	}
+	key := guildID + ":" + id
+	if cached, ok := memberCache.get(key); ok {
+		return cached.(*discordgo.Member), nil
+	}
+	member, err := API.GuildMember(guildID, id)
+	if err != nil {
+		return nil, err
+	}
+	ttl, size := cacheLimits()
+	memberCache.put(key, member, ttl, size)
+	return member, nil
+}
@@ -42,9 +42,13 @@ func NewResponse(ctx *CmdContext, deferred bool, ephemeral bool, rows int) *Resp
 			CreateEmbed(0, "", "", nil),
 		},
 	}
-	if r.Deferred && ctx.Interaction != nil {
+	// An interaction that was deferred for the command can only be edited
+	if ctx.acknowledged {
+		r.Deferred = true
+	}
+	if r.Deferred && ctx.Interaction != nil && !ctx.acknowledged {
 		if ephemeral {
-			_ = Session.InteractionRespond(r.Ctx.Interaction, &discordgo.InteractionResponse{
+			_ = API.InteractionRespond(r.Ctx.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 				Data: &discordgo.InteractionResponseData{
 					// Ephemeral is type 64 don't ask why
@@ -52,9 +56,10 @@ func NewResponse(ctx *CmdContext, deferred bool, ephemeral bool, rows int) *Resp
 				},
 			})
 		}
-		_ = Session.InteractionRespond(r.Ctx.Interaction, &discordgo.InteractionResponse{
+		_ = API.InteractionRespond(r.Ctx.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 		})
+		ctx.deferPending = true
 	}
 	if ctx.Cmd.Trigger != "" {
 		r.AppendCommand()
@@ -81,16 +86,17 @@ func (r *Response) Send(success bool, title, description string, color int) {
 	// if the guild is nil, this is supposed to be sent to bot admins
 	if r.Ctx.Guild == nil {
 		for admin := range botAdmins {
-			dmChannel, dmCreateErr := Session.UserChannelCreate(admin)
+			dmChannel, dmCreateErr := API.UserChannelCreate(admin)
 			if dmCreateErr != nil {
 				// Since error reports also use DMs, sending this as an error report would be redundant
 				// Just log the error
 				Log.Errorf("failed to send response dm to admin: %s; Response title: %s", admin, r.Embeds[0].Title)
 				return
 			}
-			_, dmSendErr := Session.ChannelMessageSendComplex(dmChannel.ID, &discordgo.MessageSend{
-				Embeds:     r.Embeds,
-				Components: r.ResponseComponents.Components,
+			_, dmSendErr := API.ChannelMessageSendComplex(dmChannel.ID, &discordgo.MessageSend{
+				Embeds:          r.Embeds,
+				Components:      r.ResponseComponents.Components,
+				AllowedMentions: r.Ctx.allowedMentions(),
 			})
 			if dmSendErr != nil {
 				// Since error reports also use DMs, sending this as an error report would be redundant
@@ -117,13 +123,14 @@ func (r *Response) Send(success bool, title, description string, color int) {
 	// Try sending the response in the configured output channel
 	// If that fails, try sending the response in the current channel
 	// If THAT fails, send an error report
-	_, err := Session.ChannelMessageSendComplex(r.Ctx.Guild.Info.ResponseChannelID, &discordgo.MessageSend{
-		Embeds:     r.Embeds,
-		Components: r.ResponseComponents.Components,
+	message, err := API.ChannelMessageSendComplex(r.Ctx.Guild.Info.ResponseChannelID, &discordgo.MessageSend{
+		Embeds:          r.Embeds,
+		Components:      r.ResponseComponents.Components,
+		AllowedMentions: r.Ctx.allowedMentions(),
 	})
 	if err != nil && r.Reply {
 		// Reply to user if no output channel
-		_, err = ReplyToUser(r.Ctx.Message.ChannelID, &discordgo.MessageSend{
+		message, err = ReplyToUser(r.Ctx.Message.ChannelID, &discordgo.MessageSend{
 			Embeds:     r.Embeds,
 			Components: r.ResponseComponents.Components,
 			Reference: &discordgo.MessageReference{
@@ -131,20 +138,22 @@ func (r *Response) Send(success bool, title, description string, color int) {
 				ChannelID: r.Ctx.Message.ChannelID,
 				GuildID:   r.Ctx.Guild.ID,
 			},
-			AllowedMentions: &discordgo.MessageAllowedMentions{
-				RepliedUser: false,
-			},
+			AllowedMentions: r.Ctx.allowedMentions(),
 		})
 		if err != nil {
 			SendErrorReport(r.Ctx.Guild.ID, r.Ctx.Message.ChannelID, r.Ctx.Message.Author.ID, "Ultimately failed to send bot response", err)
 		}
 	} else if !r.Reply {
 		// If the command does not want to reply lets just send it to the channel the command was invoked
-		_, err = Session.ChannelMessageSendComplex(r.Ctx.Message.ChannelID, &discordgo.MessageSend{
-			Embeds:     r.Embeds,
-			Components: r.ResponseComponents.Components,
+		message, err = API.ChannelMessageSendComplex(r.Ctx.Message.ChannelID, &discordgo.MessageSend{
+			Embeds:          r.Embeds,
+			Components:      r.ResponseComponents.Components,
+			AllowedMentions: r.Ctx.allowedMentions(),
 		})
 	}
+	if err == nil {
+		r.Ctx.expireResponse(message)
+	}
 }
 
 // handleInteractionResponse
@@ -152,13 +161,14 @@ func (r *Response) Send(success bool, title, description string, color int) {
 func (r *Response) handleInteractionResponse() {
 	// Check to see if the command is ephemeral (only shown to the user)
 	if r.Ephemeral {
-		err := Session.InteractionRespond(r.Ctx.Interaction, &discordgo.InteractionResponse{
+		err := API.InteractionRespond(r.Ctx.Interaction, &discordgo.InteractionResponse{
 			// Ephemeral is type 64 don't ask why
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Flags:      1 << 6,
-				Embeds:     r.Embeds,
-				Components: r.ResponseComponents.Components,
+				Flags:           1 << 6,
+				Embeds:          r.Embeds,
+				Components:      r.ResponseComponents.Components,
+				AllowedMentions: r.Ctx.allowedMentions(),
 			},
 		})
 		if err != nil {
@@ -168,26 +178,32 @@ func (r *Response) handleInteractionResponse() {
 	}
 
 	// Default response for interaction
-	err := Session.InteractionRespond(r.Ctx.Interaction, &discordgo.InteractionResponse{
+	err := API.InteractionRespond(r.Ctx.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Embeds:     r.Embeds,
-			Components: r.ResponseComponents.Components,
+			Embeds:          r.Embeds,
+			Components:      r.ResponseComponents.Components,
+			AllowedMentions: r.Ctx.allowedMentions(),
 		},
 	})
+	if err == nil {
+		r.Ctx.expireInteractionResponse()
+	}
 	if err != nil {
 		if err != nil {
 			SendErrorReport(r.Ctx.Guild.ID, r.Ctx.Interaction.ChannelID, r.Ctx.Message.Author.ID, "Unable to send interaction messages", err)
 		}
 		if r.Ctx.Guild.Info.ResponseChannelID != "" {
-			_, err = Session.ChannelMessageSendComplex(r.Ctx.Guild.Info.ResponseChannelID, &discordgo.MessageSend{
-				Embeds:     r.Embeds,
-				Components: r.ResponseComponents.Components,
+			_, err = API.ChannelMessageSendComplex(r.Ctx.Guild.Info.ResponseChannelID, &discordgo.MessageSend{
+				Embeds:          r.Embeds,
+				Components:      r.ResponseComponents.Components,
+				AllowedMentions: r.Ctx.allowedMentions(),
 			})
 		} else {
-			_, err = Session.ChannelMessageSendComplex(r.Ctx.Message.ChannelID, &discordgo.MessageSend{
-				Embeds:     r.Embeds,
-				Components: r.ResponseComponents.Components,
+			_, err = API.ChannelMessageSendComplex(r.Ctx.Message.ChannelID, &discordgo.MessageSend{
+				Embeds:          r.Embeds,
+				Components:      r.ResponseComponents.Components,
+				AllowedMentions: r.Ctx.allowedMentions(),
 			})
 		}
 
@@ -201,60 +217,79 @@ func (r *Response) handleInteractionResponse() {
 // handleDeferredResponse
 // handles responses that have been deferred.
 func (r *Response) handleDeferredResponse() {
-	// Check to see if the command is ephemeral (only shown to the interaction initiator)
-	if r.Ephemeral {
-		_, err := Session.InteractionResponseEdit(r.Ctx.Interaction, &discordgo.WebhookEdit{
-			Components: &r.ResponseComponents.Components,
-			Embeds:     &r.Embeds,
-		})
-		// Just in case the interaction has been removed
-		// Just in case the interaction gets removed.
-		if err != nil {
-			if err != nil {
-				SendErrorReport(r.Ctx.Guild.ID, r.Ctx.Interaction.ChannelID, r.Ctx.Message.Author.ID, "Unable to send interaction messages", err)
-			}
-			if r.Ctx.Guild.Info.ResponseChannelID != "" {
-				_, err = Session.ChannelMessageSendComplex(r.Ctx.Guild.Info.ResponseChannelID, &discordgo.MessageSend{
-					Embeds:     r.Embeds,
-					Components: r.ResponseComponents.Components,
-				})
-			} else {
-				_, err = Session.ChannelMessageSendComplex(r.Ctx.Message.ChannelID, &discordgo.MessageSend{
-					Embeds:     r.Embeds,
-					Components: r.ResponseComponents.Components,
-				})
-			}
-
-			if err != nil {
-				SendErrorReport(r.Ctx.Guild.ID, r.Ctx.Interaction.ChannelID, r.Ctx.Message.Author.ID, "Unable to send message", err)
-			}
-		}
-		r.Deferred = false
-		return
-	}
-
-	// Just respond normally
-	_, err := Session.InteractionResponseEdit(r.Ctx.Interaction, &discordgo.WebhookEdit{
-		Embeds:     &r.Embeds,
-		Components: &r.ResponseComponents.Components,
+	message, err := EditInteractionResponse(r.Ctx, &discordgo.WebhookEdit{
+		Embeds:          &r.Embeds,
+		Components:      &r.ResponseComponents.Components,
+		AllowedMentions: r.Ctx.allowedMentions(),
 	})
-	// Just in case the interaction gets removed.
 	if err != nil {
-		_, err := Session.ChannelMessageSendComplex(r.Ctx.Guild.Info.ResponseChannelID, &discordgo.MessageSend{
-			Embeds:     r.Embeds,
-			Components: r.ResponseComponents.Components,
-		})
-		if err != nil {
-			_, err = Session.ChannelMessageSendComplex(r.Ctx.Message.ChannelID, &discordgo.MessageSend{
-				Embeds:     r.Embeds,
-				Components: r.ResponseComponents.Components,
-			})
-		}
+		SendErrorReport(r.Ctx.Guild.ID, r.Ctx.Interaction.ChannelID, r.Ctx.Message.Author.ID, "Unable to send message", err)
+	} else {
+		r.Ctx.expireResponse(message)
 	}
 	r.Deferred = false
 	return
 }
 
+// interactionEditWindow
+// How long Discord allows an interaction's response to be edited for.
+const interactionEditWindow = 15 * time.Minute
+
+// interactionEditMargin
+// How close to the end of the edit window an edit is no longer attempted, since it would likely fail in flight.
+const interactionEditMargin = 30 * time.Second
+
+// EditInteractionResponse
+// Edits the response to a command's interaction. If the interaction has expired, or is about to,
+// or the edit fails, the response is sent as a normal message to the response channel (or the
+// invoking channel) instead, so long-running commands don't silently lose their output.
+func EditInteractionResponse(ctx *CmdContext, edit *discordgo.WebhookEdit) (*discordgo.Message, error) {
+	created, err := discordgo.SnowflakeTimestamp(ctx.Interaction.ID)
+	if err == nil && time.Since(created) > interactionEditWindow-interactionEditMargin {
+		Log.Warningf("interaction %s is too old to edit, sending its response as a channel message", ctx.Interaction.ID)
+		return sendEditAsMessage(ctx, edit)
+	}
+	message, err := API.InteractionResponseEdit(ctx.Interaction, edit)
+	if err == nil {
+		ctx.deferPending = false
+		return message, nil
+	}
+	if isExpiredInteraction(err) {
+		Log.Warningf("interaction %s has expired, sending its response as a channel message", ctx.Interaction.ID)
+	} else {
+		Log.Errorf("unable to edit interaction %s, sending its response as a channel message: %s", ctx.Interaction.ID, err)
+	}
+	return sendEditAsMessage(ctx, edit)
+}
+
+// sendEditAsMessage
+// Sends the contents of an interaction edit as a normal message.
+func sendEditAsMessage(ctx *CmdContext, edit *discordgo.WebhookEdit) (*discordgo.Message, error) {
+	data := &discordgo.MessageSend{
+		Files:           edit.Files,
+		AllowedMentions: edit.AllowedMentions,
+	}
+	if edit.Content != nil {
+		data.Content = *edit.Content
+	}
+	if edit.Embeds != nil {
+		data.Embeds = *edit.Embeds
+	}
+	if edit.Components != nil {
+		data.Components = *edit.Components
+	}
+	channelID := ctx.Interaction.ChannelID
+	if ctx.Guild != nil && ctx.Guild.Info.ResponseChannelID != "" {
+		channelID = ctx.Guild.Info.ResponseChannelID
+	}
+	var message *discordgo.Message
+	err := sendWithRetry(func() (err error) {
+		message, err = API.ChannelMessageSendComplex(channelID, data)
+		return err
+	})
+	return message, err
+}
+
 // -- Embeds --
 
 // CreateEmbed
@@ -414,10 +449,11 @@ func (r *Response) AppendButton(label string, style discordgo.ButtonStyle, url s
 func (r *Response) AppendCommand() {
 	// Get the command used as a string, and all interpreted arguments, so it can be a part of the output
 	commandUsed := ""
+	prefix, _ := r.Ctx.Guild.Prefixes()
 	if r.Ctx.Cmd.IsChild {
-		commandUsed = fmt.Sprintf("%s%s %s", r.Ctx.Guild.Info.Prefix, r.Ctx.Cmd.ParentID, r.Ctx.Cmd.Trigger)
+		commandUsed = fmt.Sprintf("%s%s %s", prefix, r.Ctx.Cmd.ParentID, r.Ctx.Cmd.Trigger)
 	} else {
-		commandUsed = r.Ctx.Guild.Info.Prefix + r.Ctx.Cmd.Trigger
+		commandUsed = prefix + r.Ctx.Cmd.Trigger
 	}
 	// Just makes the thing prettier
 	if r.Ctx.Interaction != nil {
@@ -471,6 +507,65 @@ func (r *Response) AppendCommand() {
 
 	r.AppendField(0, "Command used:", commandUsed, false)
 }
-func ReplyToUser(channelID string, messageSend *discordgo.MessageSend) (*discordgo.Message, error) {
-	return Session.ChannelMessageSendComplex(channelID, messageSend)
+
+// sendNotice
+// Sends a short plain text notice to whoever invoked a command, for things like refusing to run it
+// Interactions get an ephemeral response, and messages get a reply.
+func sendNotice(ctx *CmdContext, content string) {
+	// A deferred interaction can't be responded to again, so the notice replaces the deferred message
+	if ctx.Interaction != nil && ctx.acknowledged {
+		if _, err := EditInteractionResponse(ctx, &discordgo.WebhookEdit{Content: &content}); err != nil {
+			Log.Errorf("unable to send notice to interaction %s: %s", ctx.Interaction.ID, err)
+		}
+		return
+	}
+	if ctx.Interaction != nil {
+		err := sendWithRetry(func() error {
+			return API.InteractionRespond(ctx.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Flags:   discordgo.MessageFlagsEphemeral,
+					Content: content,
+				},
+			})
+		})
+		if err != nil {
+			Log.Errorf("unable to send notice to interaction %s: %s", ctx.Interaction.ID, err)
+		}
+		return
+	}
+	if ctx.Message == nil {
+		return
+	}
+	_, err := ReplyToMessage(ctx.Message, &discordgo.MessageSend{
+		Content:         content,
+		AllowedMentions: ctx.allowedMentions(),
+	})
+	if err != nil {
+		Log.Errorf("unable to send notice to channel %s: %s", ctx.Message.ChannelID, err)
+	}
+}
+
+// ReplyToMessage
+// Sends a message as a reply to another message, retrying if the send fails for a transient reason
+// If the message being replied to is gone (e.g: it was deleted), the message is sent without the reply instead.
+func ReplyToMessage(message *discordgo.Message, messageSend *discordgo.MessageSend) (*discordgo.Message, error) {
+	messageSend.Reference = message.Reference()
+	sent, err := ReplyToUser(message.ChannelID, messageSend)
+	if isInvalidReference(err) {
+		Log.Debugf("message %s can't be replied to, sending without a reply: %s", message.ID, err)
+		messageSend.Reference = nil
+		sent, err = ReplyToUser(message.ChannelID, messageSend)
+	}
+	return sent, err
+}
+
+// ReplyToUser
+// Sends a message to a channel, retrying if the send fails for a transient reason.
+func ReplyToUser(channelID string, messageSend *discordgo.MessageSend) (message *discordgo.Message, err error) {
+	err = sendWithRetry(func() error {
+		message, err = API.ChannelMessageSendComplex(channelID, messageSend)
+		return err
+	})
+	return message, err
 }
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// responsettl.go
+// This file contains the deletion of throwaway command output, see CommandInfo.ResponseTTL
+// Deletions are scheduled jobs, so output is still cleaned up after a restart
+
+// deleteResponseJob
+// The name of the scheduled job that deletes a command's response.
+const deleteResponseJob = "core:deleteresponse"
+
+// SetResponseTTL
+// Sets how long the command's responses stay up before they are deleted. Zero means they are never deleted.
+func (cI *CommandInfo) SetResponseTTL(ttl time.Duration) *CommandInfo {
+	cI.ResponseTTL = ttl
+	return cI
+}
+
+// expireResponse
+// Schedules a message the command sent to be deleted once the command's ResponseTTL is up.
+// Ephemeral messages are skipped, since only the user sees them and they can't be deleted from the channel.
+func (ctx *CmdContext) expireResponse(message *discordgo.Message) {
+	if ctx.Cmd.ResponseTTL <= 0 || message == nil || message.ID == "" || message.Flags&discordgo.MessageFlagsEphemeral != 0 {
+		return
+	}
+	if _, err := ScheduleAfter(ctx.Cmd.ResponseTTL, deleteResponseJob, message.ChannelID+"/"+message.ID); err != nil {
+		Log.Errorf("unable to schedule deletion of message %s: %s", message.ID, err)
+	}
+}
+
+// expireInteractionResponse
+// Looks up the message an interaction was answered with, so it can be deleted once the command's ResponseTTL is up.
+func (ctx *CmdContext) expireInteractionResponse() {
+	if ctx.Cmd.ResponseTTL <= 0 || ctx.Interaction == nil {
+		return
+	}
+	message, err := API.InteractionResponse(ctx.Interaction)
+	if err != nil {
+		Log.Errorf("unable to look up the response to interaction %s: %s", ctx.Interaction.ID, err)
+		return
+	}
+	ctx.expireResponse(message)
+}
+
+// deleteResponse
+// Deletes a message given as channelID/messageID. Messages that were already deleted are ignored.
+func deleteResponse(data string) {
+	channelID, messageID, ok := strings.Cut(data, "/")
+	if !ok {
+		Log.Errorf("invalid response to delete: %s", data)
+		return
+	}
+	if err := API.ChannelMessageDelete(channelID, messageID); err != nil && !isInvalidReference(err) {
+		Log.Errorf("unable to delete message %s in %s: %s", messageID, channelID, err)
+	}
+}
+
+func init() {
+	AddScheduledJob(deleteResponseJob, deleteResponse)
+}
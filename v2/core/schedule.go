@@ -0,0 +1,154 @@
+package core
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// schedule.go
+// This file contains the scheduler for running jobs after a delay
+// Pending jobs are saved with the guild provider, so they still run after a restart
+
+// JobFunc
+// The function a scheduled job runs, given the data it was scheduled with.
+type JobFunc func(data string)
+
+// ScheduledJob
+// A job waiting to run.
+type ScheduledJob struct {
+	ID    string
+	Job   string    // The name the job's function was added under, see AddScheduledJob
+	Data  string    // Passed to the job's function when it runs
+	RunAt time.Time // When the job should run
+}
+
+// jobFuncs
+// The functions scheduled jobs can run, keyed by name
+// These are only added while the bot is starting, so they are read-only once the bot is running.
+var jobFuncs = make(map[string]JobFunc)
+
+// scheduler
+// The pending jobs and their timers, keyed by job ID.
+var scheduler = struct {
+	sync.Mutex
+	jobs   map[string]ScheduledJob
+	timers map[string]*time.Timer
+	nextID uint64
+}{
+	jobs:   make(map[string]ScheduledJob),
+	timers: make(map[string]*time.Timer),
+}
+
+// AddScheduledJob
+// Adds a function that can be scheduled with ScheduleAfter. This should be called from init,
+// so the function exists before jobs saved from a previous run are reloaded.
+func AddScheduledJob(name string, fn JobFunc) {
+	jobFuncs[name] = fn
+}
+
+// ScheduleAfter
+// Runs the job added under the given name after d, passing it data, and returns the new job's ID
+// Jobs are referenced by name rather than by function, since functions can't be saved and reloaded.
+func ScheduleAfter(d time.Duration, job string, data string) (string, error) {
+	if _, ok := jobFuncs[job]; !ok {
+		return "", fmt.Errorf("there is no scheduled job named %s", job)
+	}
+	scheduler.Lock()
+	defer scheduler.Unlock()
+	scheduler.nextID++
+	scheduled := ScheduledJob{
+		ID:    strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatUint(scheduler.nextID, 36),
+		Job:   job,
+		Data:  data,
+		RunAt: time.Now().Add(d),
+	}
+	scheduler.jobs[scheduled.ID] = scheduled
+	startJob(scheduled)
+	saveScheduledJobs()
+	return scheduled.ID, nil
+}
+
+// CancelScheduled
+// Cancels a pending job. Returns false if the job has already run, or never existed.
+func CancelScheduled(id string) bool {
+	scheduler.Lock()
+	defer scheduler.Unlock()
+	if _, ok := scheduler.jobs[id]; !ok {
+		return false
+	}
+	scheduler.timers[id].Stop()
+	delete(scheduler.jobs, id)
+	delete(scheduler.timers, id)
+	saveScheduledJobs()
+	return true
+}
+
+// startJob
+// Starts the timer for a job. Jobs that are overdue run straight away.
+// The scheduler must be locked when this is called.
+func startJob(job ScheduledJob) {
+	scheduler.timers[job.ID] = time.AfterFunc(time.Until(job.RunAt), func() {
+		runScheduledJob(job)
+	})
+}
+
+// runScheduledJob
+// Removes a job from the pending jobs, then runs it.
+func runScheduledJob(job ScheduledJob) {
+	scheduler.Lock()
+	if _, ok := scheduler.jobs[job.ID]; !ok {
+		// The job was cancelled while its timer was firing
+		scheduler.Unlock()
+		return
+	}
+	delete(scheduler.jobs, job.ID)
+	delete(scheduler.timers, job.ID)
+	saveScheduledJobs()
+	scheduler.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			Log.Errorf("Scheduled job %s (%s) panicked: %v\n%s", job.ID, job.Job, r, debug.Stack())
+		}
+	}()
+	jobFuncs[job.Job](job.Data)
+}
+
+// saveScheduledJobs
+// Saves the pending jobs with the guild provider.
+// The scheduler must be locked when this is called, so saves can't be written out of order.
+func saveScheduledJobs() {
+	if currentProvider.SaveJobs == nil {
+		return
+	}
+	jobs := make([]ScheduledJob, 0, len(scheduler.jobs))
+	for _, job := range scheduler.jobs {
+		jobs = append(jobs, job)
+	}
+	currentProvider.SaveJobs(jobs)
+}
+
+// loadScheduledJobs
+// Reloads the jobs that were pending when the bot last stopped.
+func loadScheduledJobs() {
+	if currentProvider.LoadJobs == nil {
+		return
+	}
+	jobs := currentProvider.LoadJobs()
+	scheduler.Lock()
+	defer scheduler.Unlock()
+	for _, job := range jobs {
+		if _, ok := jobFuncs[job.Job]; !ok {
+			Log.Warningf("Dropping scheduled job %s, there is no job named %s", job.ID, job.Job)
+			continue
+		}
+		scheduler.jobs[job.ID] = job
+		startJob(job)
+	}
+	if len(jobs) > 0 {
+		Log.Infof("Loaded %d scheduled jobs", len(scheduler.jobs))
+	}
+}
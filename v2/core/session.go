@@ -0,0 +1,41 @@
+package core
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// session.go
+// This file contains the interface the core uses to make requests to Discord, so it can be swapped out in tests
+
+// SessionAPI
+// The discordgo.Session methods the core uses to make requests to Discord
+// *discordgo.Session satisfies this, and tests can provide their own implementation.
+type SessionAPI interface {
+	ApplicationCommandBulkOverwrite(appID string, guildID string, commands []*discordgo.ApplicationCommand) ([]*discordgo.ApplicationCommand, error)
+	ApplicationCommandDelete(appID string, guildID string, cmdID string) error
+	ApplicationCommands(appID string, guildID string) ([]*discordgo.ApplicationCommand, error)
+	Channel(channelID string) (*discordgo.Channel, error)
+	ChannelMessage(channelID string, messageID string) (*discordgo.Message, error)
+	ChannelMessageDelete(channelID string, messageID string) error
+	ChannelMessageEditComplex(m *discordgo.MessageEdit) (*discordgo.Message, error)
+	ChannelMessageSend(channelID string, content string) (*discordgo.Message, error)
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend) (*discordgo.Message, error)
+	ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed) (*discordgo.Message, error)
+	ChannelMessageSendReply(channelID string, content string, reference *discordgo.MessageReference) (*discordgo.Message, error)
+	ChannelTyping(channelID string) error
+	FollowupMessageCreate(interaction *discordgo.Interaction, wait bool, data *discordgo.WebhookParams) (*discordgo.Message, error)
+	GuildMember(guildID string, userID string) (*discordgo.Member, error)
+	GuildMembersSearch(guildID string, query string, limit int) ([]*discordgo.Member, error)
+	InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse) error
+	InteractionResponse(interaction *discordgo.Interaction) (*discordgo.Message, error)
+	InteractionResponseEdit(interaction *discordgo.Interaction, newresp *discordgo.WebhookEdit) (*discordgo.Message, error)
+	MessageReactionAdd(channelID string, messageID string, emojiID string) error
+	User(userID string) (*discordgo.User, error)
+	UserChannelCreate(recipientID string) (*discordgo.Channel, error)
+}
+
+// API
+// What the core makes requests to Discord through. CreateSession sets this to Session
+// Session is still used for the gateway connection and the state cache, so tests that replace API
+// only need to give Session a State.
+var API SessionAPI
@@ -0,0 +1,212 @@
+package core
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// mockSession
+// A SessionAPI that records what would have been sent to Discord
+// Methods that aren't implemented here panic through the nil embedded interface, so tests notice.
+type mockSession struct {
+	SessionAPI
+	mu        sync.Mutex
+	sent      []*discordgo.MessageSend
+	responses []*discordgo.InteractionResponse
+	typing    []string
+	reactions []string
+	followups []*discordgo.WebhookParams
+	edits     []*discordgo.WebhookEdit
+	lookups   int
+	// reactionErr is returned by MessageReactionAdd, once it has added this many reactions
+	reactionErr   error
+	reactionLimit int
+}
+
+func (m *mockSession) ChannelMessageSend(channelID string, content string) (*discordgo.Message, error) {
+	return m.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{Content: content})
+}
+
+func (m *mockSession) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend) (*discordgo.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, data)
+	return &discordgo.Message{ID: strconv.Itoa(len(m.sent)), ChannelID: channelID, Content: data.Content, Embeds: data.Embeds}, nil
+}
+
+func (m *mockSession) InteractionRespond(_ *discordgo.Interaction, resp *discordgo.InteractionResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.responses = append(m.responses, resp)
+	return nil
+}
+
+func (m *mockSession) ChannelTyping(channelID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.typing = append(m.typing, channelID)
+	return nil
+}
+
+func (m *mockSession) FollowupMessageCreate(_ *discordgo.Interaction, _ bool, data *discordgo.WebhookParams) (*discordgo.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.followups = append(m.followups, data)
+	return &discordgo.Message{Content: data.Content}, nil
+}
+
+func (m *mockSession) InteractionResponseEdit(_ *discordgo.Interaction, edit *discordgo.WebhookEdit) (*discordgo.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.edits = append(m.edits, edit)
+	return &discordgo.Message{ID: "edited"}, nil
+}
+
+func (m *mockSession) MessageReactionAdd(_ string, _ string, emojiID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.reactionErr != nil && len(m.reactions) >= m.reactionLimit {
+		return m.reactionErr
+	}
+	m.reactions = append(m.reactions, emojiID)
+	return nil
+}
+
+func (m *mockSession) User(userID string) (*discordgo.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lookups++
+	return &discordgo.User{ID: userID}, nil
+}
+
+func (m *mockSession) Channel(channelID string) (*discordgo.Channel, error) {
+	return &discordgo.Channel{ID: channelID}, nil
+}
+
+// useMockSession
+// Replaces API with a mockSession and gives Session an empty state, restoring both when the test ends.
+func useMockSession(t *testing.T) *mockSession {
+	t.Helper()
+	oldAPI, oldSession := API, Session
+	mock := &mockSession{}
+	state := discordgo.NewState()
+	state.User = &discordgo.User{ID: "100000000000000000"}
+	API = mock
+	Session = &discordgo.Session{State: state}
+	t.Cleanup(func() {
+		API, Session = oldAPI, oldSession
+	})
+	return mock
+}
+
+func TestSendNoticeUsesAPI(t *testing.T) {
+	mock := useMockSession(t)
+
+	sendNotice(&CmdContext{Message: &discordgo.Message{ID: "1", ChannelID: "2"}}, "hello")
+	if len(mock.sent) != 1 || mock.sent[0].Content != "hello" {
+		t.Fatalf("expected a single reply with the notice, got %+v", mock.sent)
+	}
+	if mock.sent[0].Reference == nil || mock.sent[0].Reference.MessageID != "1" {
+		t.Errorf("expected the notice to reply to the invoking message")
+	}
+
+	sendNotice(&CmdContext{Interaction: &discordgo.Interaction{ID: "3"}}, "hi")
+	if len(mock.responses) != 1 || mock.responses[0].Data.Flags != discordgo.MessageFlagsEphemeral {
+		t.Fatalf("expected a single ephemeral interaction response, got %+v", mock.responses)
+	}
+}
+
+func TestAddReactions(t *testing.T) {
+	mock := useMockSession(t)
+	oldSpacing := reactionSpacing
+	reactionSpacing = 0
+	t.Cleanup(func() { reactionSpacing = oldSpacing })
+
+	if err := AddReactions("1", "2", []string{"⬅️", "<:next:123456789012345678>"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(mock.reactions) != 2 || mock.reactions[1] != "next:123456789012345678" {
+		t.Fatalf("expected both reactions in the format used when reacting, got %v", mock.reactions)
+	}
+
+	// The message is deleted after the first reaction, so the rest are skipped
+	mock.reactions = nil
+	mock.reactionErr = &discordgo.RESTError{Message: &discordgo.APIErrorMessage{Code: discordgo.ErrCodeUnknownMessage}}
+	mock.reactionLimit = 1
+	if err := AddReactions("1", "2", []string{"⬅️", "➡️", "⏹️"}); err == nil {
+		t.Errorf("expected the deleted message to be reported")
+	}
+	if len(mock.reactions) != 1 {
+		t.Errorf("expected to stop after the message was deleted, got %v", mock.reactions)
+	}
+}
+
+func TestResponseTTL(t *testing.T) {
+	useMockSession(t)
+
+	ctx := &CmdContext{Cmd: CommandInfo{ResponseTTL: time.Hour}, Message: &discordgo.Message{ID: "1", ChannelID: "2"}}
+	if err := ctx.Reply("heads"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	scheduler.Lock()
+	var scheduled []ScheduledJob
+	for _, job := range scheduler.jobs {
+		if job.Job == deleteResponseJob {
+			scheduled = append(scheduled, job)
+		}
+	}
+	scheduler.Unlock()
+	for _, job := range scheduled {
+		CancelScheduled(job.ID)
+	}
+	if len(scheduled) != 1 || scheduled[0].Data != "2/1" {
+		t.Fatalf("expected the reply to be scheduled for deletion, got %+v", scheduled)
+	}
+
+	// Zero means the response is kept
+	ctx.Cmd.ResponseTTL = 0
+	if err := ctx.Reply("tails"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	scheduler.Lock()
+	defer scheduler.Unlock()
+	for _, job := range scheduler.jobs {
+		if job.Job == deleteResponseJob {
+			t.Errorf("expected no deletion to be scheduled, got %+v", job)
+		}
+	}
+}
+
+func TestResolveUserCache(t *testing.T) {
+	mock := useMockSession(t)
+	SetResolveCache(time.Hour, 2)
+	t.Cleanup(func() { SetResolveCache(5*time.Minute, 1000) })
+
+	for i := 0; i < 3; i++ {
+		if user, err := ResolveUser("<@200000000000000000>"); err != nil || user.ID != "200000000000000000" {
+			t.Fatalf("unexpected lookup result: %+v %v", user, err)
+		}
+	}
+	if mock.lookups != 1 {
+		t.Errorf("expected one request for repeated lookups, got %d", mock.lookups)
+	}
+
+	// The cache only holds two users, so the first one is dropped for the third
+	_, _ = ResolveUser("300000000000000000")
+	_, _ = ResolveUser("400000000000000000")
+	_, _ = ResolveUser("200000000000000000")
+	if mock.lookups != 4 {
+		t.Errorf("expected the oldest user to be dropped when the cache is full, got %d requests", mock.lookups)
+	}
+
+	SetResolveCache(0, 0)
+	_, _ = ResolveUser("200000000000000000")
+	_, _ = ResolveUser("200000000000000000")
+	if mock.lookups != 6 {
+		t.Errorf("expected every lookup to make a request with the cache off, got %d requests", mock.lookups)
+	}
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// slashdiff.go
+// This file compares the slash commands the bot defines with the ones Discord has registered, without changing either
+
+// SlashDiff
+// The differences between the slash commands the bot defines and the ones Discord has registered, see DiffSlashCommands.
+type SlashDiff struct {
+	Missing []string            // Commands the bot defines that Discord doesn't have, sorted
+	Extra   []string            // Commands Discord has that the bot doesn't define, sorted
+	Changed map[string][]string // Commands both have, but with different options, keyed by name
+}
+
+// Empty
+// Check if the bot and Discord agree on every slash command.
+func (d *SlashDiff) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Changed) == 0
+}
+
+// DiffSlashCommands
+// Compares the slash commands the bot would register with the ones Discord has, globally and in the given guild
+// Only chat input commands are compared, since other types are left alone when registering.
+func DiffSlashCommands(guildID string) (*SlashDiff, error) {
+	registered := make(map[string]*discordgo.ApplicationCommand)
+	scopes := []string{""}
+	if guildID != "" {
+		scopes = append(scopes, guildID)
+	}
+	for _, scope := range scopes {
+		cmds, err := API.ApplicationCommands(Session.State.User.ID, scope)
+		if err != nil {
+			return nil, err
+		}
+		for _, cmd := range cmds {
+			if cmd.Type == discordgo.ChatApplicationCommand || cmd.Type == 0 {
+				registered[cmd.Name] = cmd
+			}
+		}
+	}
+	return diffSlashCommands(desiredSlashCommands(guildID), registered), nil
+}
+
+// desiredSlashCommands
+// Returns the valid slash commands the bot would register globally, or in the given guild.
+func desiredSlashCommands(guildID string) map[string]*discordgo.ApplicationCommand {
+	desired := make(map[string]*discordgo.ApplicationCommand)
+	global, guildCommands, _ := splitSlashCommands()
+	for _, cmd := range global {
+		desired[cmd.Name] = cmd
+	}
+	if guildID != "" {
+		for _, cmd := range commandsForGuild(guildID, guildCommands) {
+			desired[cmd.Name] = cmd
+		}
+	}
+	return desired
+}
+
+// diffSlashCommands
+// Compares the desired commands with the registered ones, both keyed by name.
+func diffSlashCommands(desired, registered map[string]*discordgo.ApplicationCommand) *SlashDiff {
+	diff := &SlashDiff{Changed: make(map[string][]string)}
+	for name, want := range desired {
+		have, ok := registered[name]
+		if !ok {
+			diff.Missing = append(diff.Missing, name)
+			continue
+		}
+		var changes []string
+		if want.Description != have.Description {
+			changes = append(changes, "description differs")
+		}
+		changes = append(changes, diffOptions("", want.Options, have.Options)...)
+		if len(changes) > 0 {
+			diff.Changed[name] = changes
+		}
+	}
+	for name := range registered {
+		if _, ok := desired[name]; !ok {
+			diff.Extra = append(diff.Extra, name)
+		}
+	}
+	sort.Strings(diff.Missing)
+	sort.Strings(diff.Extra)
+	return diff
+}
+
+// diffOptions
+// Describes how the registered options differ from the desired ones, descending into sub commands.
+// Each difference is prefixed with the path of the option it is about.
+func diffOptions(path string, want, have []*discordgo.ApplicationCommandOption) []string {
+	haveByName := make(map[string]*discordgo.ApplicationCommandOption, len(have))
+	for _, opt := range have {
+		haveByName[opt.Name] = opt
+	}
+	var changes []string
+	seen := make(map[string]bool, len(want))
+	for _, w := range want {
+		seen[w.Name] = true
+		name := strings.TrimSpace(path + " " + w.Name)
+		h, ok := haveByName[w.Name]
+		switch {
+		case !ok:
+			changes = append(changes, name+": not registered")
+			continue
+		case w.Type != h.Type:
+			changes = append(changes, fmt.Sprintf("%s: type %d is registered as %d", name, w.Type, h.Type))
+			continue
+		}
+		if w.Required != h.Required {
+			changes = append(changes, fmt.Sprintf("%s: required is %t, registered as %t", name, w.Required, h.Required))
+		}
+		if w.Description != h.Description {
+			changes = append(changes, name+": description differs")
+		}
+		if len(w.Choices) != len(h.Choices) {
+			changes = append(changes, fmt.Sprintf("%s: %d choices, registered with %d", name, len(w.Choices), len(h.Choices)))
+		}
+		changes = append(changes, diffOptions(name, w.Options, h.Options)...)
+	}
+	for _, h := range have {
+		if !seen[h.Name] {
+			changes = append(changes, strings.TrimSpace(path+" "+h.Name)+": registered, but not defined")
+		}
+	}
+	return changes
+}
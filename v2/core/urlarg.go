@@ -0,0 +1,82 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// urlarg.go
+// This file contains the parser used by URL args, which only accept absolute http and https links
+
+// ParseURL
+// Parses an absolute http or https URL, stripping the <> used to suppress its embed. Links with a username or
+// password are rejected. If allowedHosts isn't empty, the URL's host must be one of them or a subdomain of one.
+func ParseURL(in string, allowedHosts []string) (*url.URL, error) {
+	in = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(in), "<"), ">")
+	u, err := url.Parse(in)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a link", in)
+	}
+	if scheme := strings.ToLower(u.Scheme); scheme != "http" && scheme != "https" {
+		return nil, fmt.Errorf("%q is not an http or https link", in)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("%q has no host", in)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("%q can't include a username or password", in)
+	}
+	if len(allowedHosts) > 0 && !hostAllowed(u.Hostname(), allowedHosts) {
+		return nil, fmt.Errorf("links to %s aren't allowed", u.Hostname())
+	}
+	return u, nil
+}
+
+// hostAllowed
+// Check if a host is one of the allowed hosts, or a subdomain of one.
+func hostAllowed(host string, allowedHosts []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range allowedHosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAllowedHosts
+// Limits a URL arg to links to the given hosts, or their subdomains.
+func (cI *CommandInfo) SetAllowedHosts(arg string, hosts ...string) *CommandInfo {
+	v, ok := cI.Arguments.Get(arg)
+	if !ok {
+		Log.Errorf("Unable to get argument %s in SetAllowedHosts", arg)
+		return cI
+	}
+	vv := v.(*ArgInfo)
+	if vv.TypeGuard != URL {
+		Log.Errorf("Argument %s on command %s is not a URL arg, allowed hosts are ignored", arg, cI.Trigger)
+		return cI
+	}
+	vv.AllowedHosts = hosts
+	cI.Arguments.Set(arg, vv)
+	return cI
+}
+
+// validateURL
+// The argValidators entry for URL args, which also checks the arg's allowed hosts, so commands can rely on URLValue.
+func validateURL(value string, info *ArgInfo) (string, error) {
+	u, err := ParseURL(value, info.AllowedHosts)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// URLValue
+// Returns the value of the arg as a URL, see ParseURL. Invalid URLs are nil.
+func (ag CommandArg) URLValue() *url.URL {
+	u, _ := ParseURL(ag.StringValue(), nil)
+	return u
+}
@@ -1,9 +1,16 @@
 package core
 
 import (
+	"bytes"
 	"errors"
+	"net"
+	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/dlclark/regexp2"
@@ -89,33 +96,21 @@ func CleanID(in string) string {
 
 // ExtractCommand
 // Given a message, attempt to extract a command trigger and command arguments out of it
-// If there is no prefix, try using a bot mention as the prefix.
-func ExtractCommand(guild *GuildInfo, message string) (*string, *string) {
+// If there is no prefix, try the extra prefix characters, then a bot mention as the prefix.
+// Messages that open with inline code or a code block are pasted snippets, not commands, even if the prefix is a backtick.
+func ExtractCommand(prefix string, prefixChars string, message string) (*string, *string) {
+	if startsWithCode(message) {
+		return nil, nil
+	}
 	// Check if the message starts with the bot trigger
-	if strings.HasPrefix(message, guild.Prefix) {
-		// Split the message on the prefix, but ensure only 2 fields are returned
-		// This ensures messages containing multiple instances of the prefix don't split multiple times
-		split := strings.SplitN(message, guild.Prefix, 2)
-
+	if strings.HasPrefix(message, prefix) {
 		// Get everything after the prefix as the command content
-		content := split[1]
-
-		// If the content is blank, someone used the prefix without a trigger
-		if content == "" {
-			return nil, nil
-		}
-
-		// Attempt to pull the trigger out of the command content by splitting on spaces
-		trigger := strings.Fields(content)[0]
-
-		// With the trigger identified, split the command content on the trigger to obtain everything BUT the trigger
-		// Ensure only 2 fields are returned so it can be split further. Then, get only the second field
-		fullArgs := strings.SplitN(content, trigger, 2)[1]
-		fullArgs = strings.TrimPrefix(fullArgs, " ")
-		// Avoids issues with strings that are case sensitive
-		trigger = strings.ToLower(trigger)
-
-		return &trigger, &fullArgs
+		// Only the first prefix is removed, so messages containing multiple instances of the prefix keep the rest
+		return splitCommand(strings.TrimPrefix(message, prefix))
+	}
+	// Any of the extra prefix characters work the same as the prefix
+	if first, size := utf8.DecodeRuneInString(message); size > 0 && first != utf8.RuneError && strings.ContainsRune(prefixChars, first) {
+		return splitCommand(message[size:])
 	}
 	// The bot can only be mentioned with a space
 	botMention := Session.State.User.Mention() + " "
@@ -125,29 +120,146 @@ func ExtractCommand(guild *GuildInfo, message string) (*string, *string) {
 
 	// See if someone is trying to mention the bot
 	if strings.HasPrefix(message, botMention) {
-		// Same process as above prefix method, but split on a bot mention instead
-		split := strings.SplitN(message, botMention, 2)
-		content := split[1]
-		// If content is null someone just sent the prefix
-		if content == "" {
-			return nil, nil
-		}
-		trigger := strings.ToLower(strings.Fields(content)[0])
-		fullArgs := strings.SplitN(content, trigger, 2)[1]
-		return &trigger, &fullArgs
+		// Same process as above prefix method, but with a bot mention instead
+		return splitCommand(strings.TrimPrefix(message, botMention))
 	}
 	return nil, nil
 }
 
+// startsWithCode
+// Checks if a message opens with inline code or a fenced code block, which may span several lines.
+// A run of backticks only opens code if a run of the same length closes it later, the same as in Discord's markdown.
+func startsWithCode(message string) bool {
+	n := len(message) - len(strings.TrimLeft(message, "`"))
+	if n == 0 {
+		return false
+	}
+	fence := message[:n]
+	rest := message[n:]
+	for {
+		i := strings.Index(rest, fence)
+		if i < 0 {
+			return false
+		}
+		// The closing run must be exactly as long as the opening one
+		after := rest[i+n:]
+		if !strings.HasPrefix(after, "`") {
+			return true
+		}
+		rest = strings.TrimLeft(after, "`")
+	}
+}
+
+// isBareMention
+// Checks if a message is only a mention of the bot, with no command after it.
+func isBareMention(message string) bool {
+	if Session == nil || Session.State == nil || Session.State.User == nil {
+		return false
+	}
+	message = strings.TrimSpace(message)
+	id := Session.State.User.ID
+	return message == "<@"+id+">" || message == "<@!"+id+">"
+}
+
+// splitCommand
+// Splits command content (everything after the prefix) into its trigger and the rest of the arguments
+// If the content is blank, someone used the prefix without a trigger, so nil is returned for both.
+func splitCommand(content string) (*string, *string) {
+	content = strings.TrimLeftFunc(content, unicode.IsSpace)
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	// Multi-word aliases are tried first, so "be right back" isn't taken as the trigger "be"
+	if phrase, n := matchPhraseAlias(fields); n > 0 {
+		rest := content
+		for _, word := range fields[:n] {
+			rest = strings.TrimPrefix(strings.TrimLeftFunc(rest, unicode.IsSpace), word)
+		}
+		fullArgs := strings.TrimPrefix(rest, " ")
+		return &phrase, &fullArgs
+	}
+	// With the trigger identified, everything after it is the arguments
+	trigger := fields[0]
+	fullArgs := strings.TrimPrefix(strings.TrimPrefix(content, trigger), " ")
+	// Avoids issues with strings that are case sensitive
+	trigger = strings.ToLower(trigger)
+
+	return &trigger, &fullArgs
+}
+
+// durationRegex
+// Matches a duration made up of numbers and units, e.g: 1h30m.
+var durationRegex = regexp.MustCompile(`^([0-9]+[smhdwy])+$`)
+
+// durationUnits
+// The length of each unit a duration can use. A year is 52 weeks.
+var durationUnits = map[byte]time.Duration{
+	's': time.Second,
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+	'y': 52 * 7 * 24 * time.Hour,
+}
+
+// maxDuration
+// The longest duration ParseDuration accepts, which also keeps the maths from overflowing.
+const maxDuration = 100 * 52 * 7 * 24 * time.Hour
+
+// ParseDuration
+// Parses a duration made up of numbers and units, using the same units as Time args (e.g: 1h30m or 2w).
+func ParseDuration(content string) (time.Duration, bool) {
+	content = strings.ToLower(content)
+	if !durationRegex.MatchString(content) {
+		return 0, false
+	}
+	var duration time.Duration
+	number := 0
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		if c >= '0' && c <= '9' {
+			number = number*10 + int(c-'0')
+			if time.Duration(number) > maxDuration/time.Second {
+				return 0, false
+			}
+			continue
+		}
+		unit := durationUnits[c]
+		if time.Duration(number) > (maxDuration-duration)/unit {
+			return 0, false
+		}
+		duration += time.Duration(number) * unit
+		number = 0
+	}
+	return duration, true
+}
+
+// FormatDuration
+// Formats a duration for people to read, in days, hours, minutes and seconds (e.g: 1h 30m, or 12s).
+// Durations are rounded up to the second, so a wait is never shown as shorter than it is.
+func FormatDuration(d time.Duration) string {
+	if d <= 0 {
+		return "0s"
+	}
+	seconds := int64((d + time.Second - 1) / time.Second)
+	var parts []string
+	for _, unit := range []struct {
+		suffix string
+		length int64
+	}{{"d", 86400}, {"h", 3600}, {"m", 60}, {"s", 1}} {
+		if seconds >= unit.length {
+			parts = append(parts, strconv.FormatInt(seconds/unit.length, 10)+unit.suffix)
+			seconds %= unit.length
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
 // GetUser
 // Given a user ID, get that user's object (global to Discord, not in a guild).
 func GetUser(userID string) (*discordgo.User, error) {
-	cleanedID := CleanID(userID)
-	if cleanedID == "" {
-		return nil, errors.New("provided ID is invalid")
-	}
-
-	return Session.User(cleanedID)
+	return ResolveUser(userID)
 }
 
 // logErrorReportFailure
@@ -168,21 +280,40 @@ func logErrorReportFailure(recipient string, dmErr error, guildId string, channe
 
 // SendErrorReport
 // Send an error report as a DM to all of the registered bot administrators.
+// The report is also kept in the recent errors buffer (see RecentErrors).
 func SendErrorReport(guildId string, channelId string, userId string, title string, err error) {
+	sendErrorReport("", guildId, channelId, userId, title, err)
+}
+
+// sendErrorReport
+// Sends an error report, recording which command was running when it happened.
+func sendErrorReport(command string, guildId string, channelId string, userId string, title string, err error) {
 	// Log a general error
 	Log.Errorf("[REPORT] %s (%s)", title, err)
 
+	message := title
+	if err != nil {
+		message += ": " + err.Error()
+	}
+	recordError(ErrorRecord{
+		Message:   message,
+		Command:   command,
+		GuildID:   guildId,
+		ChannelID: channelId,
+		UserID:    userId,
+	})
+
 	// Iterate through all the admins
 	for admin := range botAdmins {
 		// Get the channel ID of the user to DM
-		dmChannel, dmCreateErr := Session.UserChannelCreate(admin)
+		dmChannel, dmCreateErr := API.UserChannelCreate(admin)
 		if dmCreateErr != nil {
 			logErrorReportFailure(admin, dmCreateErr, guildId, channelId, userId, title, err)
 			continue
 		}
 
 		// Create a generic embed
-		reportEmbed := CreateEmbed(ColorFailure, "ERROR REPORT", title, nil)
+		reportEmbed := CreateEmbed(ColorFailure, "ERROR REPORT", truncate(title, maxEmbedDescriptionLength), nil)
 
 		// Add fields if they aren't blank
 		if guildId != "" {
@@ -210,14 +341,18 @@ func SendErrorReport(guildId string, channelId string, userId string, title stri
 		}
 
 		if err != nil {
+			// Discord rejects the whole embed if a field is too long, so long errors are cut short
 			reportEmbed.Fields = append(reportEmbed.Fields, &discordgo.MessageEmbedField{
 				Name:   "Full error:",
-				Value:  err.Error(),
+				Value:  truncate(err.Error(), maxEmbedFieldLength),
 				Inline: false,
 			})
 		}
 
-		_, dmSendErr := Session.ChannelMessageSendEmbed(dmChannel.ID, reportEmbed)
+		dmSendErr := sendWithRetry(func() error {
+			_, err := API.ChannelMessageSendEmbed(dmChannel.ID, reportEmbed)
+			return err
+		})
 		if dmSendErr != nil {
 			logErrorReportFailure(admin, dmSendErr, guildId, channelId, userId, title, err)
 			continue
@@ -225,6 +360,101 @@ func SendErrorReport(guildId string, channelId string, userId string, title stri
 	}
 }
 
+// The most characters Discord allows in an embed's description and in a field's value.
+const (
+	maxEmbedDescriptionLength = 4096
+	maxEmbedFieldLength       = 1024
+)
+
+// truncate
+// Cuts s down to at most limit characters, ending it with an ellipsis if anything was cut.
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
+
+// sendRetries
+// How many times a send that failed for a transient reason is retried.
+var sendRetries = 2
+
+// sendRetryDelay
+// How long to wait before the first retry. The delay doubles after every attempt.
+var sendRetryDelay = 500 * time.Millisecond
+
+// sendWithRetry
+// Runs fn, retrying with exponential backoff while it fails with a transient error.
+// Errors that won't go away by retrying (like 403 or 404) are returned straight away.
+func sendWithRetry(fn func() error) error {
+	delay := sendRetryDelay
+	err := fn()
+	for attempt := 0; attempt < sendRetries && isTransientError(err); attempt++ {
+		// Wait at least as long as discord asked us to, if we were rate limited
+		wait := delay
+		var rateLimitErr *discordgo.RateLimitError
+		if errors.As(err, &rateLimitErr) && rateLimitErr.RetryAfter > wait {
+			wait = rateLimitErr.RetryAfter
+		}
+		time.Sleep(wait)
+		delay *= 2
+		err = fn()
+	}
+	return err
+}
+
+// isExpiredInteraction
+// Check if an error from Discord means an interaction's token has expired, or is otherwise no longer usable.
+func isExpiredInteraction(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Message == nil {
+		return false
+	}
+	switch restErr.Message.Code {
+	case discordgo.ErrCodeUnknownWebhook, discordgo.ErrCodeUnknownInteraction, discordgo.ErrCodeInvalidWebhookTokenProvided:
+		return true
+	}
+	return false
+}
+
+// isInvalidReference
+// Check if an error from Discord means a reply's message reference is no longer valid, e.g: the message was deleted.
+func isInvalidReference(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Message == nil {
+		return false
+	}
+	switch restErr.Message.Code {
+	case discordgo.ErrCodeUnknownMessage:
+		return true
+	case discordgo.ErrCodeInvalidFormBody:
+		return bytes.Contains(restErr.ResponseBody, []byte("message_reference"))
+	}
+	return false
+}
+
+// isTransientError
+// Checks if an error is worth retrying: server errors, rate limits, and network timeouts.
+func isTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) {
+		return restErr.Response != nil && (restErr.Response.StatusCode >= 500 || restErr.Response.StatusCode == http.StatusTooManyRequests)
+	}
+	var rateLimitErr *discordgo.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout()
+	}
+	return false
+}
+
 // IsDevEnv
 // utility method to see if the environment is dev.
 func IsDevEnv() bool {
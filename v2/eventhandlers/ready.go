@@ -16,7 +16,9 @@ func readyEventHandler(s *discordgo.Session, evt *discordgo.Ready) {
 	core.WorkerManager.AddWorker("presence", workers.Worker{Duration: "0 */12 * * *", WorkerFunc: UpdatePresence})
 	// Update slash commands, if not bypassed
 	if os.Getenv("BYPASS_SLASH_REG") != "true" {
-		core.RegisterSlashCommands()
+		if err := core.RegisterSlashCommands(); err != nil {
+			core.Log.Error(err.Error())
+		}
 	}
 	// Add all registered workers
 	if core.WorkerManager.IsRunning != true {
@@ -26,6 +28,10 @@ func readyEventHandler(s *discordgo.Session, evt *discordgo.Ready) {
 }
 
 func UpdatePresence() {
+	// A presence set by an admin takes over from the default one
+	if core.ApplyPresence() {
+		return
+	}
 	shard := core.Session.ShardID
 	err := core.Session.UpdateStatusComplex(discordgo.UpdateStatusData{
 		Status: string(discordgo.StatusDoNotDisturb),
@@ -47,4 +53,5 @@ func UpdatePresence() {
 
 func init() {
 	core.AddHandler(readyEventHandler)
+	core.SetDefaultPresence(UpdatePresence)
 }
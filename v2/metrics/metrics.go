@@ -0,0 +1,81 @@
+// Package metrics exports command metrics to Prometheus
+// It is a separate package so bots that don't import it don't pull in the Prometheus client.
+package metrics
+
+import (
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	bot "github.com/ubergeek77/uberbot/v2/core"
+)
+
+// metrics.go
+// Counts command runs, panics and latency, labeled by trigger and guild, and serves them on /metrics
+
+var (
+	invocations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "uberbot",
+		Name:      "command_invocations_total",
+		Help:      "How many times each command was run.",
+	}, []string{"trigger", "guild", "type"})
+	failures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "uberbot",
+		Name:      "command_errors_total",
+		Help:      "How many runs of each command panicked.",
+	}, []string{"trigger", "guild", "type"})
+	latency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "uberbot",
+		Name:      "command_duration_seconds",
+		Help:      "How long each command took to run.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"trigger", "guild", "type"})
+)
+
+// register
+// Registers the collectors and the command observer, only once no matter how many servers are started.
+var register sync.Once
+
+// observe
+// Records a single command run.
+func observe(run bot.CommandRun) {
+	guild := run.GuildID
+	if guild == "" {
+		guild = "dm"
+	}
+	kind := "message"
+	if run.Slash {
+		kind = "slash"
+	}
+	invocations.WithLabelValues(run.Trigger, guild, kind).Inc()
+	if run.Panicked {
+		failures.WithLabelValues(run.Trigger, guild, kind).Inc()
+	}
+	latency.WithLabelValues(run.Trigger, guild, kind).Observe(run.Duration.Seconds())
+}
+
+// StartMetricsServer
+// Starts serving command metrics on addr (e.g: ":9090") at /metrics, in the background
+// Returns an error if addr can't be listened on. Metrics are only collected once this has been called.
+func StartMetricsServer(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(invocations, failures, latency)
+	register.Do(func() {
+		bot.AddCommandObserver(observe)
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			bot.Log.Errorf("metrics server stopped: %s", err)
+		}
+	}()
+	bot.Log.Infof("serving metrics on %s/metrics", listener.Addr())
+	return nil
+}
@@ -28,6 +28,23 @@ var GuildsDir = "./guilds"
 // This ensures files are written to synchronously, avoiding file race conditions.
 var saveLock = make(map[string]*sync.Mutex)
 
+// saveLockGuard
+// Guards saveLock itself, since guilds are saved and reloaded from different goroutines.
+var saveLockGuard sync.Mutex
+
+// guildSaveLock
+// Returns the mutex for a guild's file, creating it if needed.
+func guildSaveLock(guildID string) *sync.Mutex {
+	saveLockGuard.Lock()
+	defer saveLockGuard.Unlock()
+	lock, ok := saveLock[guildID]
+	if !ok {
+		lock = &sync.Mutex{}
+		saveLock[guildID] = lock
+	}
+	return lock
+}
+
 // loadGuilds
 // Load all known guilds from the filesystem, from inside GuildsDir.
 func loadGuilds() (guilds map[string]*core.Guild) {
@@ -120,19 +137,29 @@ func loadGuilds() (guilds map[string]*core.Guild) {
 	return guilds
 }
 
+// loadGuild
+// Load a single guild's info from the filesystem, waiting for any in-progress save of that guild to finish.
+func loadGuild(guildID string) (core.GuildInfo, error) {
+	var gInfo core.GuildInfo
+	lock := guildSaveLock(guildID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	jsonBytes, err := ioutil.ReadFile(path.Join(GuildsDir, guildID+".json"))
+	if err != nil {
+		return gInfo, err
+	}
+	err = json.Unmarshal(jsonBytes, &gInfo)
+	return gInfo, err
+}
+
 // save
 // Save a given guild object to .json.
 func save(g *core.Guild) {
-	// See if a mutex exists for this guild, and create if not
-	if _, ok := saveLock[g.ID]; !ok {
-		saveLock[g.ID] = &sync.Mutex{}
-	}
-
-	// Unlock writing when done
-	defer saveLock[g.ID].Unlock()
-
-	// Mark this guild as locked before saving
-	saveLock[g.ID].Lock()
+	// Mark this guild as locked before saving, and unlock writing when done
+	lock := guildSaveLock(g.ID)
+	lock.Lock()
+	defer lock.Unlock()
 
 	// Create the output directory if it doesn't exist
 	// This is a fatal error, since no other guilds would be savable if this fails
@@ -157,11 +184,123 @@ func save(g *core.Guild) {
 	}
 }
 
+// saveJSON
+// Save v to a .json file with the given name, inside GuildsDir.
+func saveJSON(name string, v interface{}) {
+	if err := os.MkdirAll(GuildsDir, 0755); err != nil {
+		log.Errorf("Failed to create guild output directory: %s", err)
+		return
+	}
+	jsonBytes, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		log.Errorf("Failed marshalling JSON data for %s: %s", name, err)
+		return
+	}
+	outPath := path.Join(GuildsDir, name)
+	if err = ioutil.WriteFile(outPath, jsonBytes, 0644); err != nil {
+		log.Errorf("Write failed to %s: %s", outPath, err)
+	}
+}
+
+// loadJSON
+// Load a .json file with the given name from inside GuildsDir into v, returning whether it was loaded.
+// A file that doesn't exist isn't an error, since nothing has been saved to it yet.
+func loadJSON(name string, v interface{}) bool {
+	inPath := path.Join(GuildsDir, name)
+	jsonBytes, err := ioutil.ReadFile(inPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("Failed to read \"%s\"; it WILL NOT be loaded! (%s)", inPath, err)
+		}
+		return false
+	}
+	if err = json.Unmarshal(jsonBytes, v); err != nil {
+		log.Errorf("Failed to unmarshal \"%s\"; it WILL NOT be loaded! (%s)", inPath, err)
+		return false
+	}
+	return true
+}
+
+// jobsFile
+// The name of the file pending scheduled jobs are saved to, inside GuildsDir
+// It is not a snowflake, so it is never mistaken for a guild.
+const jobsFile = "scheduled.json"
+
+// saveJobs
+// Save the pending scheduled jobs to .json.
+func saveJobs(jobs []core.ScheduledJob) {
+	saveJSON(jobsFile, jobs)
+}
+
+// loadJobs
+// Load the scheduled jobs that were pending when the bot last stopped.
+func loadJobs() []core.ScheduledJob {
+	var jobs []core.ScheduledJob
+	if !loadJSON(jobsFile, &jobs) {
+		return nil
+	}
+	return jobs
+}
+
+// remapsFile
+// The name of the file trigger remaps are saved to, inside GuildsDir.
+const remapsFile = "remaps.json"
+
+// saveRemaps
+// Save the trigger remaps to .json.
+func saveRemaps(remaps []core.TriggerRemap) {
+	saveJSON(remapsFile, remaps)
+}
+
+// loadRemaps
+// Load the trigger remaps, in the order they were made.
+func loadRemaps() []core.TriggerRemap {
+	var remaps []core.TriggerRemap
+	if !loadJSON(remapsFile, &remaps) {
+		return nil
+	}
+	return remaps
+}
+
+// presenceFile
+// The name of the file the bot's presence is saved to, inside GuildsDir.
+const presenceFile = "presence.json"
+
+// savePresence
+// Save the bot's presence to .json, or remove the file if the presence was reset.
+func savePresence(presence *core.Presence) {
+	if presence == nil {
+		outPath := path.Join(GuildsDir, presenceFile)
+		if err := os.Remove(outPath); err != nil && !os.IsNotExist(err) {
+			log.Errorf("Failed to remove %s: %s", outPath, err)
+		}
+		return
+	}
+	saveJSON(presenceFile, presence)
+}
+
+// loadPresence
+// Load the bot's saved presence, if there is one.
+func loadPresence() *core.Presence {
+	var presence core.Presence
+	if !loadJSON(presenceFile, &presence) {
+		return nil
+	}
+	return &presence
+}
+
 // InitProvider
 // Inits the filesystem provider.
 func InitProvider() core.GuildProvider {
 	return core.GuildProvider{
-		Save: save,
-		Load: loadGuilds,
+		Save:         save,
+		Load:         loadGuilds,
+		LoadGuild:    loadGuild,
+		SaveJobs:     saveJobs,
+		LoadJobs:     loadJobs,
+		SaveRemaps:   saveRemaps,
+		LoadRemaps:   loadRemaps,
+		SavePresence: savePresence,
+		LoadPresence: loadPresence,
 	}
 }